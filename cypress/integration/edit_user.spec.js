@@ -9,6 +9,9 @@ describe("Edit user", () => {
         cy.get("#f-firstname").type("2");
         cy.get("button[type=submit]").click();
 
+        cy.contains(".govuk-table", "First name");
+        cy.contains("button", "Confirm changes").click();
+
         cy.contains(".moj-banner", "You have successfully edited a user.");
     });
 });
@@ -0,0 +1,13 @@
+describe("Reactivate user", () => {
+    beforeEach(() => {
+        cy.setCookie("Other", "other");
+        cy.setCookie("XSRF-TOKEN", "abcde");
+        cy.visit("/reactivate-user/123");
+    });
+
+    it("allows me to reactivate a user", () => {
+        cy.get("#f-reason").type("Back from leave");
+        cy.contains("button", "Reactivate user").click();
+        cy.url().should("include", "/users/123");
+    });
+});
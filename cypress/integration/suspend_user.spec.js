@@ -0,0 +1,21 @@
+describe("Suspend user", () => {
+    beforeEach(() => {
+        cy.setCookie("Other", "other");
+        cy.setCookie("XSRF-TOKEN", "abcde");
+        cy.visit("/suspend-user/123");
+    });
+
+    it("requires a reason", () => {
+        cy.contains("button", "Suspend user").click();
+        cy.get(".govuk-error-summary").should("contain", "Enter a reason for suspending the user");
+    });
+
+    it("allows me to suspend a user", () => {
+        cy.get("#f-reason").type("On extended leave");
+        cy.get("#f-review-date").type("1");
+        cy.get("#f-review-month").type("1");
+        cy.get("#f-review-year").type("2100");
+        cy.contains("button", "Suspend user").click();
+        cy.url().should("include", "/users/123");
+    });
+});
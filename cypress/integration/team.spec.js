@@ -10,11 +10,11 @@ describe("Team", () => {
     });
 
     it("shows team members", () => {
-        cy.get(".govuk-table__row").should("have.length", 2);
+        cy.get("#members .govuk-table__row").should("have.length", 2);
 
         const expected = ["Select", "John", "john@opgtest.com"];
 
-        cy.get(".govuk-table__body > .govuk-table__row")
+        cy.get("#members .govuk-table__body > .govuk-table__row")
             .children()
             .each(($el, index) => {
                 cy.wrap($el).should("contain", expected[index]);
@@ -32,6 +32,15 @@ describe("Team", () => {
     it("allows me to remove team members", () => {
         cy.contains(".govuk-button", "Remove selected from team");
 
-        cy.get(".govuk-table__body > .govuk-table__row input[type=checkbox]").should("have.length", 1);
+        cy.get("#members .govuk-table__body > .govuk-table__row input[type=checkbox]").should("have.length", 1);
+    });
+
+    it("allows me to move team members", () => {
+        cy.contains(".govuk-button", "Move selected to another team");
+    });
+
+    it("shows the history of team members", () => {
+        cy.contains(".govuk-tabs__tab", "History").click();
+        cy.get("#history").should("be.visible");
     });
 });
@@ -27,6 +27,26 @@ describe("Teams", () => {
         cy.get("button[type=submit]").click();
 
         cy.get(".govuk-table__body > .govuk-table__row").should("have.length", 0);
+        cy.contains("No teams found");
+    });
+
+    it("allows me to filter teams by type", () => {
+        cy.get("#f-type").select("LPA");
+        cy.get("button[type=submit]").click();
+
+        cy.get(".govuk-table__body > .govuk-table__row").should("have.length", 0);
+
+        cy.get("#f-type").select("Supervision");
+        cy.get("button[type=submit]").click();
+
+        cy.get(".govuk-table__body > .govuk-table__row").should("have.length", 1);
+    });
+
+    it("allows me to sort teams", () => {
+        cy.contains(".govuk-table__header a", "Members").click();
+
+        cy.url().should("contain", "sort=members");
+        cy.get(".govuk-table__header[aria-sort=ascending]").should("contain", "Members");
     });
 
     it("allows me to add a new team", () => {
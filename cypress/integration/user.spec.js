@@ -0,0 +1,18 @@
+describe("User", () => {
+    beforeEach(() => {
+        cy.setCookie("Other", "other");
+        cy.setCookie("XSRF-TOKEN", "abcde");
+        cy.visit("/users/123");
+    });
+
+    it("shows the user's details", () => {
+        cy.contains(".govuk-summary-list__key", "Organisation");
+        cy.contains(".govuk-summary-list__key", "Roles");
+        cy.contains(".govuk-heading-m", "Teams");
+        cy.contains(".govuk-link", "Cool Team");
+    });
+
+    it("allows me to edit the user", () => {
+        cy.contains(".govuk-button", "Edit user");
+    });
+});
@@ -26,4 +26,25 @@ describe("Users", () => {
                 cy.wrap($el).should("contain", expected[index]);
             });
     });
+
+    it("allows me to filter the search results", () => {
+        cy.get("#f-search").clear().type("admin");
+        cy.get("#f-status").select("Locked");
+        cy.get("button[type=submit]").click();
+
+        cy.url().should("include", "status=Locked");
+        cy.get(".govuk-table").should("not.exist");
+        cy.contains("No users found matching search term");
+
+        cy.get("#f-status").select("Active");
+        cy.get("button[type=submit]").click();
+
+        cy.get(".govuk-table__body > .govuk-table__row").should("have.length", 1);
+    });
+
+    it("asks for a search before filtering", () => {
+        cy.visit("/users?status=Active");
+        cy.get(".govuk-error-summary").should("contain", "Enter a search term to filter users");
+        cy.get(".govuk-table").should("not.exist");
+    });
 });
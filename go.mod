@@ -4,5 +4,6 @@ go 1.14
 
 require (
 	github.com/pact-foundation/pact-go v1.4.4
+	github.com/prometheus/client_golang v1.9.0
 	github.com/stretchr/testify v1.6.1
 )
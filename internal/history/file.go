@@ -0,0 +1,95 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// file holds values as lines of JSON. Lines are only ever appended, so reading
+// means scanning the whole file.
+type file struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func openFile(path string) (*file, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := endLine(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &file{path: path, file: f}, nil
+}
+
+// endLine finishes off any line left by a partial write, so that the next
+// entry is not lost along with it.
+func endLine(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+
+	if last[0] != '\n' {
+		_, err = file.Write([]byte("\n"))
+	}
+
+	return err
+}
+
+func (f *file) append(values ...interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+
+	for _, v := range values {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	return f.file.Sync()
+}
+
+// scan calls fn with each line in the order they were written.
+func (f *file) scan(fn func([]byte)) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	r, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fn(scanner.Bytes())
+	}
+
+	return scanner.Err()
+}
+
+func (f *file) close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,78 @@
+package history
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type Direction string
+
+const (
+	Joined = Direction("joined")
+	Left   = Direction("left")
+)
+
+type Entry struct {
+	TeamID    int       `json:"team_id"`
+	UserID    int       `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	ActorID   int       `json:"actor_id"`
+	ActorName string    `json:"actor_name"`
+	Timestamp time.Time `json:"timestamp"`
+	Direction Direction `json:"direction"`
+}
+
+// Store keeps entries as lines of JSON in a single file. Entries are only ever
+// appended, so a team's history is found by reading the whole file. Only one
+// process should write to the file at a time.
+type Store struct {
+	file *file
+}
+
+func Open(path string) (*Store, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{file: file}, nil
+}
+
+func (s *Store) Append(entries ...Entry) error {
+	values := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		values[i] = entry
+	}
+
+	return s.file.append(values...)
+}
+
+// Team returns the entries for a team, most recent first. A line that cannot
+// be decoded, such as one left by a partial write, is skipped.
+func (s *Store) Team(id int) ([]Entry, error) {
+	var entries []Entry
+
+	err := s.file.scan(func(line []byte) {
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return
+		}
+
+		if entry.TeamID == id {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	return entries, nil
+}
+
+func (s *Store) Close() error {
+	return s.file.close()
+}
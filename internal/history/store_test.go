@@ -0,0 +1,104 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempStore(t *testing.T) (*Store, string) {
+	dir, err := ioutil.TempDir("", "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "history.jsonl")
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return store, path
+}
+
+func TestStore(t *testing.T) {
+	assert := assert.New(t)
+
+	store, _ := tempStore(t)
+	now := time.Date(2020, time.October, 1, 9, 0, 0, 0, time.UTC)
+
+	assert.Nil(store.Append(
+		Entry{TeamID: 1, UserID: 10, ActorID: 5, Timestamp: now, Direction: Joined},
+		Entry{TeamID: 2, UserID: 11, ActorID: 5, Timestamp: now, Direction: Joined},
+	))
+	assert.Nil(store.Append(
+		Entry{TeamID: 1, UserID: 10, ActorID: 6, Timestamp: now.Add(time.Hour), Direction: Left},
+	))
+
+	entries, err := store.Team(1)
+	assert.Nil(err)
+	assert.Equal([]Entry{
+		{TeamID: 1, UserID: 10, ActorID: 6, Timestamp: now.Add(time.Hour), Direction: Left},
+		{TeamID: 1, UserID: 10, ActorID: 5, Timestamp: now, Direction: Joined},
+	}, entries)
+
+	entries, err = store.Team(3)
+	assert.Nil(err)
+	assert.Nil(entries)
+}
+
+func TestStoreReopen(t *testing.T) {
+	assert := assert.New(t)
+
+	store, path := tempStore(t)
+	assert.Nil(store.Append(Entry{TeamID: 1, UserID: 10, Direction: Joined}))
+	assert.Nil(store.Close())
+
+	store, err := Open(path)
+	assert.Nil(err)
+	defer store.Close()
+
+	assert.Nil(store.Append(Entry{TeamID: 1, UserID: 11, Direction: Joined}))
+
+	entries, err := store.Team(1)
+	assert.Nil(err)
+	assert.Len(entries, 2)
+}
+
+func TestStoreSkipsPartialLines(t *testing.T) {
+	assert := assert.New(t)
+
+	store, path := tempStore(t)
+	assert.Nil(store.Append(Entry{TeamID: 1, UserID: 10, Direction: Joined}))
+
+	file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	_, _ = file.WriteString(`{"team_id":1,"us`)
+	file.Close()
+
+	entries, err := store.Team(1)
+	assert.Nil(err)
+	assert.Len(entries, 1)
+
+	assert.Nil(store.Close())
+	store, err = Open(path)
+	assert.Nil(err)
+	defer store.Close()
+
+	assert.Nil(store.Append(Entry{TeamID: 1, UserID: 11, Direction: Joined}))
+
+	entries, err = store.Team(1)
+	assert.Nil(err)
+	assert.Len(entries, 2)
+}
+
+func TestOpenError(t *testing.T) {
+	_, err := Open(filepath.Join("does", "not", "exist", "history.jsonl"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,76 @@
+package history
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+type Suspension struct {
+	UserID     int        `json:"user_id"`
+	UserName   string     `json:"user_name"`
+	UserEmail  string     `json:"user_email"`
+	Suspended  bool       `json:"suspended"`
+	Reason     string     `json:"reason"`
+	ReviewDate *time.Time `json:"review_date,omitempty"`
+	Timestamp  time.Time  `json:"timestamp"`
+}
+
+// Suspensions records each time a user is suspended or reactivated, so that
+// suspensions can be reviewed. Only the latest record for a user is current.
+// As with Store, only one process should write to the file at a time.
+type Suspensions struct {
+	file *file
+}
+
+func OpenSuspensions(path string) (*Suspensions, error) {
+	file, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Suspensions{file: file}, nil
+}
+
+func (s *Suspensions) Append(suspension Suspension) error {
+	return s.file.append(suspension)
+}
+
+// ReviewDue returns the users that are still suspended and whose review date
+// is on or before now, with the longest overdue first.
+func (s *Suspensions) ReviewDue(now time.Time) ([]Suspension, error) {
+	latest := map[int]Suspension{}
+
+	err := s.file.scan(func(line []byte) {
+		var suspension Suspension
+		if err := json.Unmarshal(line, &suspension); err != nil {
+			return
+		}
+
+		latest[suspension.UserID] = suspension
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var due []Suspension
+	for _, suspension := range latest {
+		if suspension.Suspended && suspension.ReviewDate != nil && !suspension.ReviewDate.After(now) {
+			due = append(due, suspension)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].ReviewDate.Equal(*due[j].ReviewDate) {
+			return due[i].UserID < due[j].UserID
+		}
+
+		return due[i].ReviewDate.Before(*due[j].ReviewDate)
+	})
+
+	return due, nil
+}
+
+func (s *Suspensions) Close() error {
+	return s.file.close()
+}
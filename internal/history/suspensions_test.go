@@ -0,0 +1,68 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempSuspensions(t *testing.T) *Suspensions {
+	dir, err := ioutil.TempDir("", "suspensions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	suspensions, err := OpenSuspensions(filepath.Join(dir, "suspensions.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { suspensions.Close() })
+
+	return suspensions
+}
+
+func TestSuspensionsReviewDue(t *testing.T) {
+	assert := assert.New(t)
+
+	suspensions := tempSuspensions(t)
+	now := time.Date(2020, time.October, 1, 9, 0, 0, 0, time.UTC)
+	lastWeek := now.AddDate(0, 0, -7)
+	yesterday := now.AddDate(0, 0, -1)
+	tomorrow := now.AddDate(0, 0, 1)
+
+	for _, suspension := range []Suspension{
+		{UserID: 1, Suspended: true, Reason: "Leave", ReviewDate: &yesterday},
+		{UserID: 2, Suspended: true, Reason: "Leave", ReviewDate: &tomorrow},
+		{UserID: 3, Suspended: true, Reason: "Left"},
+		{UserID: 4, Suspended: true, Reason: "Leave", ReviewDate: &lastWeek},
+		{UserID: 5, Suspended: true, Reason: "Leave", ReviewDate: &lastWeek},
+		{UserID: 5, Suspended: false, Reason: "Back"},
+		{UserID: 6, Suspended: true, Reason: "Leave", ReviewDate: &now},
+	} {
+		assert.Nil(suspensions.Append(suspension))
+	}
+
+	due, err := suspensions.ReviewDue(now)
+	assert.Nil(err)
+	assert.Equal([]Suspension{
+		{UserID: 4, Suspended: true, Reason: "Leave", ReviewDate: &lastWeek},
+		{UserID: 1, Suspended: true, Reason: "Leave", ReviewDate: &yesterday},
+		{UserID: 6, Suspended: true, Reason: "Leave", ReviewDate: &now},
+	}, due)
+}
+
+func TestSuspensionsReviewDueEmpty(t *testing.T) {
+	due, err := tempSuspensions(t).ReviewDue(time.Now())
+	assert.Nil(t, err)
+	assert.Nil(t, due)
+}
+
+func TestOpenSuspensionsError(t *testing.T) {
+	_, err := OpenSuspensions(filepath.Join("does", "not", "exist", "suspensions.jsonl"))
+	assert.NotNil(t, err)
+}
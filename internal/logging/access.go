@@ -0,0 +1,182 @@
+package logging
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveFields are parts of form field names whose values are never
+// logged.
+var sensitiveFields = []string{"password", "token"}
+
+type accessEvent struct {
+	ServiceName   string            `json:"service_name"`
+	Timestamp     time.Time         `json:"timestamp"`
+	Type          string            `json:"type"`
+	Level         Level             `json:"level"`
+	RequestMethod string            `json:"request_method"`
+	RequestPath   string            `json:"request_path"`
+	RequestID     string            `json:"request_id,omitempty"`
+	Query         url.Values        `json:"query,omitempty"`
+	Form          url.Values        `json:"form,omitempty"`
+	Cookies       map[string]string `json:"cookies,omitempty"`
+	Status        int               `json:"status"`
+	Duration      float64           `json:"duration_seconds"`
+	Bytes         int               `json:"bytes"`
+	UserID        int               `json:"user_id,omitempty"`
+	Fields        Fields            `json:"fields,omitempty"`
+}
+
+type accessContextKey struct{}
+
+// accessRecord is kept on the context of a request so that a way to find the
+// user can be added once the request is handled.
+type accessRecord struct {
+	mu     sync.Mutex
+	userID func() int
+}
+
+// SetUserLookup gives a function to find the ID of the user making the
+// request, which is only called when its access log is written. It does
+// nothing for requests that are not being logged.
+func SetUserLookup(ctx context.Context, fn func() int) {
+	if record, ok := ctx.Value(accessContextKey{}).(*accessRecord); ok {
+		record.mu.Lock()
+		record.userID = fn
+		record.mu.Unlock()
+	}
+}
+
+// Access logs each request handled by next at LevelInfo, once it has been
+// responded to. Form fields for passwords and tokens, and the values of all
+// cookies, are redacted.
+func (l *Logger) Access(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// parsed here so that the form is shared with the copies of r made by
+		// later handlers
+		_ = r.ParseForm()
+
+		if LevelInfo < l.level {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		record := &accessRecord{}
+		r = r.WithContext(context.WithValue(r.Context(), accessContextKey{}, record))
+
+		rw := &accessResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		record.mu.Lock()
+		lookup := record.userID
+		record.mu.Unlock()
+
+		var userID int
+		if lookup != nil {
+			userID = lookup()
+		}
+
+		l.encode(l.redactor.Value(accessEvent{
+			ServiceName:   l.serviceName,
+			Timestamp:     start,
+			Type:          "access",
+			Level:         LevelInfo,
+			RequestMethod: r.Method,
+			RequestPath:   r.URL.Path,
+			RequestID:     requestid.FromContext(r.Context()),
+			Query:         redactValues(r.URL.Query()),
+			Form:          redactValues(r.PostForm),
+			Cookies:       redactCookies(r.Cookies()),
+			Status:        rw.Status(),
+			Duration:      time.Since(start).Seconds(),
+			Bytes:         rw.bytes,
+			UserID:        userID,
+			Fields:        l.fields,
+		}))
+	})
+}
+
+func redactValues(values url.Values) url.Values {
+	if len(values) == 0 {
+		return nil
+	}
+
+	result := url.Values{}
+	for k, v := range values {
+		if isSensitiveField(k) {
+			result[k] = []string{redacted}
+		} else {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, field := range sensitiveFields {
+		if strings.Contains(name, field) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactCookies(cookies []*http.Cookie) map[string]string {
+	if len(cookies) == 0 {
+		return nil
+	}
+
+	result := map[string]string{}
+	for _, cookie := range cookies {
+		result[cookie.Name] = redacted
+	}
+
+	return result
+}
+
+type accessResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *accessResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+
+	return n, err
+}
+
+func (w *accessResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
@@ -0,0 +1,142 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccess(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+
+	handler := logger.Access(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetUserLookup(r.Context(), func() int { return 47 })
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}))
+
+	form := url.Values{
+		"email":           {"someone@opgtest.com"},
+		"currentpassword": {"abc"},
+		"xsrfToken":       {"def"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/change-password?page=2", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(&http.Cookie{Name: "sirius", Value: "session"})
+	r = r.WithContext(requestid.WithID(r.Context(), "abc-123"))
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(http.StatusCreated, w.Result().StatusCode)
+	assert.NotContains(buf.String(), "session")
+	assert.NotContains(buf.String(), `"abc"`)
+	assert.NotContains(buf.String(), `"def"`)
+
+	var v accessEvent
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+
+	assert.Equal("hi", v.ServiceName)
+	assert.WithinDuration(time.Now(), v.Timestamp, time.Second)
+	assert.Equal("access", v.Type)
+	assert.Equal(LevelInfo, v.Level)
+	assert.Equal("POST", v.RequestMethod)
+	assert.Equal("/change-password", v.RequestPath)
+	assert.Equal("abc-123", v.RequestID)
+	assert.Equal(url.Values{"page": {"2"}}, v.Query)
+	assert.Equal(url.Values{
+		"email":           {"[REDACTED]"},
+		"currentpassword": {"[REDACTED]"},
+		"xsrfToken":       {"[REDACTED]"},
+	}, v.Form)
+	assert.Equal(map[string]string{"sirius": "[REDACTED]"}, v.Cookies)
+	assert.Equal(http.StatusCreated, v.Status)
+	assert.True(v.Duration >= 0)
+	assert.Equal(5, v.Bytes)
+	assert.Equal(47, v.UserID)
+}
+
+func TestAccessDefaultStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+
+	handler := logger.Access(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path", nil)
+
+	handler.ServeHTTP(w, r)
+
+	var v accessEvent
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+
+	assert.Equal(http.StatusOK, v.Status)
+	assert.Equal(0, v.Bytes)
+	assert.Equal(0, v.UserID)
+	assert.Nil(v.Query)
+	assert.Nil(v.Form)
+	assert.Nil(v.Cookies)
+}
+
+func TestAccessFormStillReadable(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := New(&bytes.Buffer{}, "hi")
+
+	var value string
+	handler := logger.Access(http.StripPrefix("/prefix", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value = r.PostFormValue("name")
+	})))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/prefix/path", strings.NewReader("name=team"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal("team", value)
+}
+
+func TestAccessLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	logger.SetLevel(LevelWarn)
+
+	lookups := 0
+	handler := logger.Access(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetUserLookup(r.Context(), func() int {
+			lookups++
+			return 47
+		})
+	}))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path", nil)
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(0, buf.Len())
+	assert.Equal(0, lookups)
+}
+
+func TestSetUserLookupNotLogged(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/path", nil)
+
+	assert.NotPanics(t, func() { SetUserLookup(r.Context(), func() int { return 5 }) })
+}
@@ -8,40 +8,121 @@ import (
 	"os"
 	"sync"
 	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
 )
 
+// Fields are extra values to include with each event from a Logger.
+type Fields map[string]interface{}
+
 type Logger struct {
 	serviceName string
+	level       Level
+	fields      Fields
+	redactor    *Redactor
+	out         *output
+}
 
+// output is shared by a Logger and those created from it by With, so that
+// their events are not interleaved.
+type output struct {
 	mu  sync.Mutex
-	out *json.Encoder
+	enc *json.Encoder
 }
 
 func New(out io.Writer, serviceName string) *Logger {
-	return &Logger{out: json.NewEncoder(out), serviceName: serviceName}
+	return &Logger{
+		serviceName: serviceName,
+		level:       LevelInfo,
+		redactor:    DefaultRedactor(),
+		out:         &output{enc: json.NewEncoder(out)},
+	}
+}
+
+// SetRedactor replaces the Redactor used to mask personal data, which is
+// DefaultRedactor unless changed. A nil Redactor logs events unchanged.
+//
+// Audit events keep only the ID of the actor, and have their request URI,
+// message, reason, and before and after values masked.
+func (l *Logger) SetRedactor(redactor *Redactor) {
+	l.redactor = redactor
+}
+
+// SetLevel stops events less severe than level from being logged. Audit events
+// are always logged.
+func (l *Logger) SetLevel(level Level) {
+	l.level = level
+}
+
+// With returns a Logger that adds fields to each event, along with any fields
+// already given to l.
+func (l *Logger) With(fields Fields) *Logger {
+	merged := Fields{}
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	child := *l
+	child.fields = merged
+
+	return &child
+}
+
+func (l *Logger) encode(v interface{}) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+
+	_ = l.out.enc.Encode(v)
 }
 
 type logEvent struct {
 	ServiceName string    `json:"service_name"`
 	Timestamp   time.Time `json:"timestamp"`
+	Level       Level     `json:"level"`
 	Message     string    `json:"message"`
+	Fields      Fields    `json:"fields,omitempty"`
 }
 
-func (l *Logger) Print(v ...interface{}) {
-	now := time.Now()
-
-	l.mu.Lock()
-	defer l.mu.Unlock()
+func (l *Logger) log(level Level, v ...interface{}) {
+	if level < l.level {
+		return
+	}
 
-	_ = l.out.Encode(logEvent{
+	l.encode(l.redactor.Value(logEvent{
 		ServiceName: l.serviceName,
+		Timestamp:   time.Now(),
+		Level:       level,
 		Message:     fmt.Sprint(v...),
-		Timestamp:   now,
-	})
+		Fields:      l.fields,
+	}))
+}
+
+func (l *Logger) Debug(v ...interface{}) {
+	l.log(LevelDebug, v...)
+}
+
+func (l *Logger) Info(v ...interface{}) {
+	l.log(LevelInfo, v...)
+}
+
+func (l *Logger) Warn(v ...interface{}) {
+	l.log(LevelWarn, v...)
+}
+
+func (l *Logger) Error(v ...interface{}) {
+	l.log(LevelError, v...)
+}
+
+// Print logs at LevelInfo.
+func (l *Logger) Print(v ...interface{}) {
+	l.Info(v...)
 }
 
 func (l *Logger) Fatal(err error) {
-	l.Print(err)
+	l.Error(err)
 	os.Exit(1)
 }
 
@@ -50,8 +131,11 @@ type requestEvent struct {
 	Timestamp     time.Time   `json:"timestamp"`
 	RequestMethod string      `json:"request_method"`
 	RequestURI    string      `json:"request_uri"`
+	RequestID     string      `json:"request_id,omitempty"`
+	Level         Level       `json:"level"`
 	Message       string      `json:"message"`
 	Data          interface{} `json:"data"`
+	Fields        Fields      `json:"fields,omitempty"`
 }
 
 type expandedError interface {
@@ -59,15 +143,23 @@ type expandedError interface {
 	Data() interface{}
 }
 
+// Request logs an error in handling r, at LevelError.
 func (l *Logger) Request(r *http.Request, err error) {
+	if LevelError < l.level {
+		return
+	}
+
 	now := time.Now()
 
 	event := requestEvent{
 		ServiceName:   l.serviceName,
 		RequestMethod: r.Method,
 		RequestURI:    r.URL.String(),
+		RequestID:     requestid.FromContext(r.Context()),
+		Level:         LevelError,
 		Message:       err.Error(),
 		Timestamp:     now,
+		Fields:        l.fields,
 	}
 
 	if ee, ok := err.(expandedError); ok {
@@ -75,7 +167,66 @@ func (l *Logger) Request(r *http.Request, err error) {
 		event.Data = ee.Data()
 	}
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	_ = l.out.Encode(event)
+	l.encode(l.redactor.Value(event))
+}
+
+type AuditEvent struct {
+	ActorID    int
+	ActorName  string
+	Action     string
+	TargetType string
+	TargetID   int
+	Reason     string
+	Before     interface{}
+	After      interface{}
+	Err        error
+}
+
+type auditEvent struct {
+	ServiceName   string      `json:"service_name"`
+	Timestamp     time.Time   `json:"timestamp"`
+	Type          string      `json:"type"`
+	RequestMethod string      `json:"request_method"`
+	RequestURI    string      `json:"request_uri"`
+	RequestID     string      `json:"request_id,omitempty"`
+	ActorID       int         `json:"actor_id"`
+	Action        string      `json:"action"`
+	TargetType    string      `json:"target_type,omitempty"`
+	TargetID      int         `json:"target_id,omitempty"`
+	Reason        string      `json:"reason,omitempty"`
+	Before        interface{} `json:"before,omitempty"`
+	After         interface{} `json:"after,omitempty"`
+	Outcome       string      `json:"outcome"`
+	Message       string      `json:"message,omitempty"`
+}
+
+// Audit logs e at any level. Only the ID of the actor is written, and personal
+// data in the reason and the before and after values is masked. The reason is
+// free text, so only email addresses and phone numbers are masked within it.
+func (l *Logger) Audit(r *http.Request, e AuditEvent) {
+	now := time.Now()
+
+	event := auditEvent{
+		ServiceName:   l.serviceName,
+		Timestamp:     now,
+		Type:          "audit",
+		RequestMethod: r.Method,
+		RequestURI:    l.redactor.URI(r.URL.String()),
+		RequestID:     requestid.FromContext(r.Context()),
+		ActorID:       e.ActorID,
+		Action:        e.Action,
+		TargetType:    e.TargetType,
+		TargetID:      e.TargetID,
+		Reason:        l.redactor.String(e.Reason),
+		Before:        l.redactor.Data(e.Before),
+		After:         l.redactor.Data(e.After),
+		Outcome:       "success",
+	}
+
+	if e.Err != nil {
+		event.Outcome = "failure"
+		event.Message = l.redactor.String(e.Err.Error())
+	}
+
+	l.encode(event)
 }
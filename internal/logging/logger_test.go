@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,10 +44,28 @@ func TestRequest(t *testing.T) {
 	assert.WithinDuration(time.Now(), v.Timestamp, time.Second)
 	assert.Equal("GET", v.RequestMethod)
 	assert.Equal("/something", v.RequestURI)
+	assert.Equal("", v.RequestID)
 	assert.Equal("what", v.Message)
 	assert.Nil(v.Data)
 }
 
+func TestRequestWithRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	r, _ := http.NewRequest("GET", "/something", nil)
+	r = r.WithContext(requestid.WithID(r.Context(), "abc-123"))
+
+	logger.Request(r, errors.New("what"))
+
+	var v requestEvent
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+
+	assert.Equal("abc-123", v.RequestID)
+	assert.Equal("what", v.Message)
+}
+
 type anExpandedError struct {
 	title string
 	data  interface{}
@@ -80,3 +99,197 @@ func TestRequestWithExpandedError(t *testing.T) {
 	assert.Equal(err.title, v.Message)
 	assert.Equal(err.data, v.Data)
 }
+
+func TestAudit(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	r, _ := http.NewRequest("POST", "/delete-user/5", nil)
+
+	logger.Audit(r, AuditEvent{
+		ActorID:    12,
+		ActorName:  "Anne Admin",
+		Action:     "delete-user",
+		TargetType: "user",
+		TargetID:   5,
+		Before:     map[string]interface{}{"email": "someone@opgtest.com"},
+	})
+
+	assert.NotContains(buf.String(), "Anne Admin")
+
+	var v auditEvent
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+
+	assert.Equal("hi", v.ServiceName)
+	assert.WithinDuration(time.Now(), v.Timestamp, time.Second)
+	assert.Equal("audit", v.Type)
+	assert.Equal("POST", v.RequestMethod)
+	assert.Equal("/delete-user/5", v.RequestURI)
+	assert.Equal(12, v.ActorID)
+	assert.Equal("delete-user", v.Action)
+	assert.Equal("user", v.TargetType)
+	assert.Equal(5, v.TargetID)
+	assert.Equal("", v.Reason)
+	assert.Equal(map[string]interface{}{"email": "[REDACTED]"}, v.Before)
+	assert.Nil(v.After)
+	assert.Equal("success", v.Outcome)
+	assert.Equal("", v.Message)
+}
+
+func TestAuditWithError(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	r, _ := http.NewRequest("POST", "/teams/delete/5", nil)
+
+	logger.Audit(r, AuditEvent{
+		ActorID:    12,
+		Action:     "delete-team",
+		TargetType: "team",
+		TargetID:   5,
+		Err:        errors.New("team has members"),
+	})
+
+	var v auditEvent
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+
+	assert.Equal("delete-team", v.Action)
+	assert.Equal("failure", v.Outcome)
+	assert.Equal("team has members", v.Message)
+}
+
+func TestAuditWithReason(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	r, _ := http.NewRequest("POST", "/suspend-user/5", nil)
+
+	logger.Audit(r, AuditEvent{
+		ActorID:    12,
+		Action:     "suspend-user",
+		TargetType: "user",
+		TargetID:   5,
+		Reason:     "On extended leave",
+	})
+
+	var v auditEvent
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+
+	assert.Equal("suspend-user", v.Action)
+	assert.Equal("On extended leave", v.Reason)
+	assert.Equal("success", v.Outcome)
+}
+
+func TestAuditWithRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	r, _ := http.NewRequest("POST", "/delete-user/5", nil)
+	r = r.WithContext(requestid.WithID(r.Context(), "abc-123"))
+
+	logger.Audit(r, AuditEvent{Action: "delete-user"})
+
+	var v auditEvent
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+
+	assert.Equal("abc-123", v.RequestID)
+}
+
+func TestLevels(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	logger.SetLevel(LevelWarn)
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Print("print")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	var levels []Level
+	var messages []string
+
+	decoder := json.NewDecoder(&buf)
+	for decoder.More() {
+		var v logEvent
+		assert.Nil(decoder.Decode(&v))
+
+		levels = append(levels, v.Level)
+		messages = append(messages, v.Message)
+	}
+
+	assert.Equal([]Level{LevelWarn, LevelError}, levels)
+	assert.Equal([]string{"warn", "error"}, messages)
+}
+
+func TestLevelsRequestAndAudit(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	logger.SetLevel(LevelError + 1)
+	r, _ := http.NewRequest("GET", "/something", nil)
+
+	logger.Request(r, errors.New("what"))
+	assert.Equal(0, buf.Len())
+
+	logger.Audit(r, AuditEvent{Action: "delete-user"})
+	assert.NotEqual(0, buf.Len())
+}
+
+func TestParseLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, level := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		parsed, err := ParseLevel(level.String())
+		assert.Nil(err)
+		assert.Equal(level, parsed)
+	}
+
+	_, err := ParseLevel("loud")
+	assert.Equal(`unknown log level "loud"`, err.Error())
+}
+
+func TestWith(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+
+	logger.With(Fields{"a": "one", "b": 2}).With(Fields{"a": "three"}).Info("message")
+	logger.Info("plain")
+
+	decoder := json.NewDecoder(&buf)
+
+	var v logEvent
+	assert.Nil(decoder.Decode(&v))
+	assert.Equal(LevelInfo, v.Level)
+	assert.Equal("message", v.Message)
+	assert.Equal(Fields{"a": "three", "b": float64(2)}, v.Fields)
+
+	var plain map[string]interface{}
+	assert.Nil(decoder.Decode(&plain))
+	assert.Equal("plain", plain["message"])
+	assert.NotContains(plain, "fields")
+}
+
+func TestWithRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi").With(Fields{"component": "server"})
+	r, _ := http.NewRequest("GET", "/something", nil)
+
+	logger.Request(r, errors.New("what"))
+
+	var v requestEvent
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+	assert.Equal(LevelError, v.Level)
+	assert.Equal(Fields{"component": "server"}, v.Fields)
+}
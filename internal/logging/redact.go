@@ -0,0 +1,211 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+var (
+	EmailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+(?:@|%40)[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	PhonePattern = regexp.MustCompile(`(?:\+|\b)\d[\d ()-]{8,14}\d\b`)
+)
+
+// DefaultRedactParams are the query parameters and form fields that can hold
+// personal data.
+var DefaultRedactParams = []string{
+	"search",
+	"query",
+	"email",
+	"emails",
+	"firstname",
+	"surname",
+	"name",
+	"displayname",
+	"phone",
+	"phonenumber",
+	"csv",
+	"reason",
+}
+
+// Redactor masks personal data in events before they are written. The values
+// of Params are masked wherever they appear as a query parameter or field, and
+// any text matching one of the patterns is masked everywhere.
+type Redactor struct {
+	params   map[string]bool
+	query    *regexp.Regexp
+	patterns []*regexp.Regexp
+}
+
+func NewRedactor(params []string, patterns ...*regexp.Regexp) *Redactor {
+	r := &Redactor{
+		params:   map[string]bool{},
+		patterns: patterns,
+	}
+
+	var quoted []string
+	for _, param := range params {
+		if param = strings.TrimSpace(param); param != "" {
+			r.params[strings.ToLower(param)] = true
+			quoted = append(quoted, regexp.QuoteMeta(param))
+		}
+	}
+
+	if len(quoted) > 0 {
+		r.query = regexp.MustCompile(`(?i)([?&;](?:` + strings.Join(quoted, "|") + `)=)[^&#\s"]*`)
+	}
+
+	return r
+}
+
+// eventFields are set by the logger rather than taken from the data being
+// logged, so are never masked. Request IDs and paths hold long runs of digits
+// that would otherwise be taken for phone numbers.
+var eventFields = map[string]bool{
+	"service_name":   true,
+	"timestamp":      true,
+	"type":           true,
+	"level":          true,
+	"request_method": true,
+	"request_path":   true,
+	"request_id":     true,
+}
+
+// DefaultRedactor masks email addresses, phone numbers and DefaultRedactParams.
+func DefaultRedactor() *Redactor {
+	return NewRedactor(DefaultRedactParams, EmailPattern, PhonePattern)
+}
+
+// String masks personal data in s.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+
+	if r.query != nil {
+		s = r.query.ReplaceAllString(s, "${1}"+redacted)
+	}
+
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllString(s, redacted)
+	}
+
+	return s
+}
+
+// URI masks personal data in the query of a request URI, leaving its path as
+// it is made of routes and IDs.
+func (r *Redactor) URI(s string) string {
+	if i := strings.IndexByte(s, '?'); i >= 0 {
+		return s[:i] + r.String(s[i:])
+	}
+
+	return s
+}
+
+// Value masks personal data anywhere in v, as it would be encoded to JSON. The
+// names of fields at the top level of v are not compared to the params, as they
+// belong to the event rather than the data being logged, and eventFields are
+// left unmasked.
+func (r *Redactor) Value(v interface{}) interface{} {
+	if r == nil {
+		return v
+	}
+
+	generic, ok := toGeneric(v)
+	if !ok {
+		return v
+	}
+
+	if event, ok := generic.(map[string]interface{}); ok {
+		for k, value := range event {
+			if eventFields[k] {
+				continue
+			}
+
+			if s, ok := value.(string); ok && k == "request_uri" {
+				event[k] = r.URI(s)
+			} else {
+				event[k] = r.walk(value)
+			}
+		}
+		return event
+	}
+
+	return r.walk(generic)
+}
+
+// Data masks personal data anywhere in v, as it would be encoded to JSON,
+// including fields at the top level of v.
+func (r *Redactor) Data(v interface{}) interface{} {
+	if r == nil || v == nil {
+		return v
+	}
+
+	generic, ok := toGeneric(v)
+	if !ok {
+		return v
+	}
+
+	return r.walk(generic)
+}
+
+func toGeneric(v interface{}) (interface{}, bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, false
+	}
+
+	return generic, true
+}
+
+func (r *Redactor) walk(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			if r.params[strings.ToLower(k)] {
+				v[k] = mask(value)
+			} else {
+				v[k] = r.walk(value)
+			}
+		}
+		return v
+
+	case []interface{}:
+		for i, value := range v {
+			v[i] = r.walk(value)
+		}
+		return v
+
+	case string:
+		return r.String(v)
+
+	default:
+		return v
+	}
+}
+
+// mask replaces a value, keeping it as a list if it was one so that it is
+// still read as the same type.
+func mask(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		for i := range v {
+			v[i] = redacted
+		}
+		return v
+	default:
+		return redacted
+	}
+}
@@ -0,0 +1,276 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+var personalData = []string{
+	"someone@opgtest.com",
+	"someone%40opgtest.com",
+	"Anne",
+	"Admin",
+	"07700 900123",
+	"+44 7700 900123",
+	"01234567890",
+}
+
+func assertNoPersonalData(t *testing.T, s string) {
+	for _, data := range personalData {
+		assert.NotContains(t, s, data)
+	}
+}
+
+func TestRedactorString(t *testing.T) {
+	redactor := DefaultRedactor()
+
+	testCases := map[string]string{
+		"email someone%40opgtest.com failed":               "email [REDACTED] failed",
+		"email someone@opgtest.com failed":                 "email [REDACTED] failed",
+		"call 07700 900123 or +44 7700 900123":             "call [REDACTED] or [REDACTED]",
+		"phone 01234567890.":                               "phone [REDACTED].",
+		"/users?search=Anne+Admin&page=2":                  "/users?search=[REDACTED]&page=2",
+		"/resend-confirmation?EMAIL=someone%40opgtest.com": "/resend-confirmation?EMAIL=[REDACTED]",
+		"GET /api/v1/users/123 returned 500":               "GET /api/v1/users/123 returned 500",
+		"2020-01-02T03:04:05.123456789Z":                   "2020-01-02T03:04:05.123456789Z",
+		"request 0123456789abcdef0123456789abcdef":         "request 0123456789abcdef0123456789abcdef",
+		"/teams?sort=-members&type=lpa":                    "/teams?sort=-members&type=lpa",
+	}
+
+	for input, expected := range testCases {
+		t.Run(input, func(t *testing.T) {
+			assert.Equal(t, expected, redactor.String(input))
+		})
+	}
+}
+
+func TestRedactorValue(t *testing.T) {
+	assert := assert.New(t)
+
+	v := DefaultRedactor().Value(map[string]interface{}{
+		"query": "/users?search=Anne",
+		"data": map[string]interface{}{
+			"Email":  "anything",
+			"emails": []string{"a", "b"},
+			"nested": []interface{}{map[string]interface{}{"name": "Anne Admin", "id": 5}},
+			"other":  "call someone@opgtest.com",
+			"count":  5,
+		},
+	})
+
+	data, _ := json.Marshal(v)
+	assert.JSONEq(`{
+		"query": "/users?search=[REDACTED]",
+		"data": {
+			"Email": "[REDACTED]",
+			"emails": ["[REDACTED]", "[REDACTED]"],
+			"nested": [{"name": "[REDACTED]", "id": 5}],
+			"other": "call [REDACTED]",
+			"count": 5
+		}
+	}`, string(data))
+}
+
+func TestRedactorData(t *testing.T) {
+	assert := assert.New(t)
+
+	v := DefaultRedactor().Data(map[string]interface{}{
+		"email": "anything",
+		"id":    5,
+	})
+
+	data, _ := json.Marshal(v)
+	assert.JSONEq(`{"email": "[REDACTED]", "id": 5}`, string(data))
+	assert.Nil(DefaultRedactor().Data(nil))
+}
+
+func TestNewRedactor(t *testing.T) {
+	assert := assert.New(t)
+
+	redactor := NewRedactor([]string{" team ", ""}, regexp.MustCompile(`secret`))
+
+	assert.Equal("/teams?team=[REDACTED]&search=Anne", redactor.String("/teams?team=Cool&search=Anne"))
+	assert.Equal("a [REDACTED] b someone@opgtest.com", redactor.String("a secret b someone@opgtest.com"))
+
+	var none *Redactor
+	assert.Equal("someone@opgtest.com", none.String("someone@opgtest.com"))
+	assert.Equal("someone@opgtest.com", none.Value("someone@opgtest.com"))
+	assert.Equal("someone@opgtest.com", none.Data("someone@opgtest.com"))
+}
+
+func TestLoggerRedactsPersonalData(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi").With(Fields{"email": "someone@opgtest.com"})
+
+	r, _ := http.NewRequest("GET", "/users?search=Anne+Admin&page=2", nil)
+
+	logger.Print("could not find someone@opgtest.com")
+	logger.Warn("call 07700 900123")
+	logger.Request(r, errors.New("no user with email someone@opgtest.com"))
+	logger.Request(r, anExpandedError{
+		title: "unexpected response from Sirius",
+		data: map[string]interface{}{
+			"url":    "http://sirius/api/v1/search/users?query=someone%40opgtest.com&email=someone%40opgtest.com",
+			"phone":  "+44 7700 900123",
+			"detail": "01234567890",
+		},
+	})
+
+	handler := logger.Access(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	form := "firstname=Anne&surname=Admin&email=someone%40opgtest.com&phonenumber=07700+900123"
+	post, _ := http.NewRequest("POST", "/add-user?search=Anne", strings.NewReader(form))
+	post.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(httptest.NewRecorder(), post)
+
+	assertNoPersonalData(t, buf.String())
+
+	decoder := json.NewDecoder(&buf)
+	var events []map[string]interface{}
+	for decoder.More() {
+		var v map[string]interface{}
+		assert.Nil(decoder.Decode(&v))
+		events = append(events, v)
+	}
+
+	assert.Len(events, 5)
+	assert.Equal("could not find [REDACTED]", events[0]["message"])
+	assert.Equal("/users?search=[REDACTED]&page=2", events[2]["request_uri"])
+	assert.Equal("no user with email [REDACTED]", events[2]["message"])
+	assert.Equal("unexpected response from Sirius", events[3]["message"])
+	assert.Equal("info", events[4]["level"])
+	assert.WithinDuration(time.Now(), parseTime(events[4]["timestamp"]), time.Second)
+}
+
+func TestLoggerKeepsEventFields(t *testing.T) {
+	assert := assert.New(t)
+
+	id := "123e4567-e89b-12d3-a456-426614174000"
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+
+	r, _ := http.NewRequest("GET", "/teams/12345678901?search=07700900123", nil)
+	r = r.WithContext(requestid.WithID(r.Context(), id))
+
+	logger.Request(r, errors.New("oops"))
+	logger.Access(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(httptest.NewRecorder(), r)
+	logger.Audit(r, AuditEvent{Action: "edit-team"})
+
+	decoder := json.NewDecoder(&buf)
+	var events []map[string]interface{}
+	for decoder.More() {
+		var v map[string]interface{}
+		assert.Nil(decoder.Decode(&v))
+		events = append(events, v)
+	}
+
+	assert.Len(events, 3)
+	for _, event := range events {
+		assert.Equal(id, event["request_id"])
+	}
+
+	assert.Equal("/teams/12345678901?search=[REDACTED]", events[0]["request_uri"])
+	assert.Equal("/teams/12345678901", events[1]["request_path"])
+	assert.Equal("/teams/12345678901?search=[REDACTED]", events[2]["request_uri"])
+}
+
+func TestAuditRedactsRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	r, _ := http.NewRequest("POST", "/resend-confirmation?email=someone%40opgtest.com", nil)
+
+	logger.Audit(r, AuditEvent{
+		Action: "resend-confirmation",
+		Before: map[string]interface{}{"email": "someone@opgtest.com"},
+		Err:    errors.New("could not send to someone@opgtest.com"),
+	})
+
+	var v auditEvent
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+
+	assert.Equal("/resend-confirmation?email=[REDACTED]", v.RequestURI)
+	assert.Equal("could not send to [REDACTED]", v.Message)
+	assert.Equal(map[string]interface{}{"email": "[REDACTED]"}, v.Before)
+}
+
+func TestAuditRedactsPersonalData(t *testing.T) {
+	assert := assert.New(t)
+
+	type member struct {
+		ID          int    `json:"id"`
+		DisplayName string `json:"displayName"`
+	}
+
+	type team struct {
+		ID          int      `json:"id"`
+		DisplayName string   `json:"displayName"`
+		PhoneNumber string   `json:"phoneNumber"`
+		Members     []member `json:"members"`
+	}
+
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	r, _ := http.NewRequest("POST", "/teams/remove-member/5", nil)
+
+	logger.Audit(r, AuditEvent{
+		ActorID:   12,
+		ActorName: "Anne Admin",
+		Action:    "remove-team-member",
+		TargetID:  5,
+		Reason:    "Moved at the request of anne@opgtest.com",
+		Before:    team{ID: 5, DisplayName: "Cool Team", PhoneNumber: "0123", Members: []member{{ID: 1, DisplayName: "Bob"}}},
+		After:     &team{ID: 5, DisplayName: "Cool Team", PhoneNumber: "0123"},
+	})
+
+	var v map[string]interface{}
+	assert.Nil(json.NewDecoder(&buf).Decode(&v))
+
+	assert.Nil(v["actor_name"])
+	assert.Equal(float64(12), v["actor_id"])
+	assert.Equal("Moved at the request of [REDACTED]", v["reason"])
+	assert.Equal(map[string]interface{}{
+		"id":          float64(5),
+		"displayName": "[REDACTED]",
+		"phoneNumber": "[REDACTED]",
+		"members": []interface{}{
+			map[string]interface{}{"id": float64(1), "displayName": "[REDACTED]"},
+		},
+	}, v["before"])
+	assert.Equal(map[string]interface{}{
+		"id":          float64(5),
+		"displayName": "[REDACTED]",
+		"phoneNumber": "[REDACTED]",
+		"members":     nil,
+	}, v["after"])
+}
+
+func TestSetRedactorNil(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "hi")
+	logger.SetRedactor(nil)
+
+	logger.Print("someone@opgtest.com")
+
+	assert.Contains(t, buf.String(), "someone@opgtest.com")
+}
+
+func parseTime(v interface{}) time.Time {
+	s, _ := v.(string)
+	t, _ := time.Parse(time.RFC3339Nano, s)
+
+	return t
+}
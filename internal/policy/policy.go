@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+// Policy lists the rules that the roles given to a user must follow.
+type Policy struct {
+	// Exclusive lists sets of roles where a user can have at most one.
+	Exclusive [][]string `json:"exclusive"`
+	// Requires maps a role to the roles a user must also have to be given it.
+	Requires map[string][]string `json:"requires"`
+	// Organisations maps a role to the only organisation it can be given in.
+	Organisations map[string]sirius.Organisation `json:"organisations"`
+}
+
+// Load reads a policy from a JSON file. An empty path gives a policy with no
+// rules.
+func Load(path string) (*Policy, error) {
+	if path == "" {
+		return &Policy{}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var p Policy
+	dec := json.NewDecoder(file)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("role policy %s: %w", path, err)
+	}
+
+	return &p, nil
+}
+
+// Check returns a message for each rule broken by giving the roles to a user
+// in the organisation, keyed by the kind of rule. Only the first break of each
+// kind is reported.
+func (p *Policy) Check(organisation sirius.Organisation, roles []string) map[string]string {
+	violations := map[string]string{}
+
+	for _, set := range p.Exclusive {
+		var held []string
+		for _, role := range set {
+			if contains(roles, role) {
+				held = append(held, role)
+			}
+		}
+
+		if len(held) > 1 {
+			violations["exclusive"] = fmt.Sprintf("A user cannot have both %s", strings.Join(held, " and "))
+			break
+		}
+	}
+
+	for _, role := range roles {
+		if _, ok := violations["requires"]; ok {
+			break
+		}
+
+		for _, required := range p.Requires[role] {
+			if !contains(roles, required) {
+				violations["requires"] = fmt.Sprintf("%s can only be given with %s", role, required)
+				break
+			}
+		}
+	}
+
+	for _, role := range roles {
+		if only, ok := p.Organisations[role]; ok && only != organisation {
+			violations["organisation"] = fmt.Sprintf("%s can only be given to %s accounts", role, only)
+			break
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return violations
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
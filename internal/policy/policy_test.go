@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPolicy = &Policy{
+	Exclusive: [][]string{
+		{"Finance Reporting", "Finance Manager"},
+	},
+	Requires: map[string][]string{
+		"Allocations Manager": {"Manager"},
+	},
+	Organisations: map[string]sirius.Organisation{
+		"Case Manager": "OPG User",
+	},
+}
+
+func TestCheck(t *testing.T) {
+	for name, tc := range map[string]struct {
+		organisation sirius.Organisation
+		roles        []string
+		violations   map[string]string
+	}{
+		"allowed": {
+			organisation: "OPG User",
+			roles:        []string{"Case Manager", "Allocations Manager", "Manager", "Finance Manager"},
+		},
+		"no roles": {
+			organisation: "COP User",
+		},
+		"exclusive": {
+			organisation: "OPG User",
+			roles:        []string{"Finance Manager", "Finance Reporting"},
+			violations: map[string]string{
+				"exclusive": "A user cannot have both Finance Reporting and Finance Manager",
+			},
+		},
+		"requires": {
+			organisation: "OPG User",
+			roles:        []string{"Allocations Manager"},
+			violations: map[string]string{
+				"requires": "Allocations Manager can only be given with Manager",
+			},
+		},
+		"organisation": {
+			organisation: "COP User",
+			roles:        []string{"Case Manager"},
+			violations: map[string]string{
+				"organisation": "Case Manager can only be given to OPG User accounts",
+			},
+		},
+		"several": {
+			organisation: "COP User",
+			roles:        []string{"Case Manager", "Allocations Manager", "Finance Manager", "Finance Reporting"},
+			violations: map[string]string{
+				"exclusive":    "A user cannot have both Finance Reporting and Finance Manager",
+				"requires":     "Allocations Manager can only be given with Manager",
+				"organisation": "Case Manager can only be given to OPG User accounts",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.violations, testPolicy.Check(tc.organisation, tc.roles))
+		})
+	}
+}
+
+func TestCheckEmptyPolicy(t *testing.T) {
+	assert.Nil(t, (&Policy{}).Check("OPG User", []string{"System Admin", "Manager"}))
+}
+
+func TestLoad(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "policy.json")
+	_ = ioutil.WriteFile(path, []byte(`{
+  "exclusive": [["Finance Reporting", "Finance Manager"]],
+  "requires": {"Allocations Manager": ["Manager"]},
+  "organisations": {"Case Manager": "OPG User"}
+}`), 0600)
+
+	p, err := Load(path)
+	assert.Nil(err)
+	assert.Equal(testPolicy, p)
+
+	_ = ioutil.WriteFile(path, []byte(`{"exclusve": []}`), 0600)
+	_, err = Load(path)
+	assert.NotNil(err)
+
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.NotNil(err)
+}
+
+func TestLoadEmptyPath(t *testing.T) {
+	p, err := Load("")
+	assert.Nil(t, err)
+	assert.Equal(t, &Policy{}, p)
+}
@@ -0,0 +1,35 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	assert := assert.New(t)
+
+	id := New()
+	assert.Len(id, 32)
+	assert.True(Valid(id))
+	assert.NotEqual(id, New())
+}
+
+func TestValid(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(Valid("abc-123_4.5"))
+	assert.False(Valid(""))
+	assert.False(Valid("abc def"))
+	assert.False(Valid("abc\ndef"))
+	assert.False(Valid(strings.Repeat("a", 129)))
+}
+
+func TestFromContext(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("", FromContext(context.Background()))
+	assert.Equal("abc", FromContext(WithID(context.Background(), "abc")))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -25,7 +26,7 @@ type addTeamVars struct {
 	Errors    sirius.ValidationErrors
 }
 
-func addTeam(client AddTeamClient, tmpl Template) Handler {
+func addTeam(client AddTeamClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-teams", http.MethodPost) {
 			return StatusError(http.StatusForbidden)
@@ -63,6 +64,20 @@ func addTeam(client AddTeamClient, tmpl Template) Handler {
 
 			id, err := client.AddTeam(ctx, name, teamType, phone, email)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "add-team",
+				TargetType: "team",
+				TargetID:   id,
+				After: sirius.Team{
+					ID:          id,
+					DisplayName: name,
+					Type:        teamType,
+					PhoneNumber: phone,
+					Email:       email,
+				},
+				Err: err,
+			})
+
 			if verr, ok := err.(sirius.ValidationError); ok {
 				teamTypes, err := client.TeamTypes(ctx)
 				if err != nil {
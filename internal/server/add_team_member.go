@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -19,13 +20,15 @@ type addTeamMemberVars struct {
 	XSRFToken string
 	Search    string
 	Team      sirius.Team
+	Hash      string
 	Users     []sirius.User
 	Members   map[int]bool
 	Success   string
+	Conflict  *teamConflict
 	Errors    sirius.ValidationErrors
 }
 
-func addTeamMember(client AddTeamMemberClient, tmpl Template) Handler {
+func addTeamMember(client AddTeamMemberClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-teams", http.MethodPut) {
 			return StatusError(http.StatusForbidden)
@@ -51,6 +54,7 @@ func addTeamMember(client AddTeamMemberClient, tmpl Template) Handler {
 			Path:      r.URL.Path,
 			XSRFToken: ctx.XSRFToken,
 			Team:      team,
+			Hash:      teamHash(team),
 		}
 
 		if r.Method == http.MethodPost {
@@ -59,10 +63,25 @@ func addTeamMember(client AddTeamMemberClient, tmpl Template) Handler {
 				return err
 			}
 
-			team.Members = append(team.Members, sirius.TeamMember{ID: memberID})
+			team.Members = append(team.Members, sirius.TeamMember{ID: memberID, Email: r.PostFormValue("email")})
+
+			if r.PostFormValue("hash") != vars.Hash {
+				vars.Conflict = newTeamConflict(r, vars.Team, team)
+				w.WriteHeader(http.StatusConflict)
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
 
 			err = client.EditTeam(ctx, team)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "add-team-member",
+				TargetType: "team",
+				TargetID:   id,
+				Before:     vars.Team,
+				After:      team,
+				Err:        err,
+			})
+
 			if _, ok := err.(sirius.ClientError); ok {
 				vars.Errors = sirius.ValidationErrors{
 					"search": {
@@ -76,6 +95,7 @@ func addTeamMember(client AddTeamMemberClient, tmpl Template) Handler {
 			} else if err != nil {
 				return err
 			} else {
+				vars.Hash = teamHash(team)
 				vars.Success = r.PostFormValue("email")
 			}
 		}
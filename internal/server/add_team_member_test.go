@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -71,7 +72,7 @@ func TestGetAddTeamMember(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/add-member/123", nil)
 
-	err := addTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -85,6 +86,7 @@ func TestGetAddTeamMember(t *testing.T) {
 	assert.Equal("page", template.lastName)
 	assert.Equal(addTeamMemberVars{
 		Path: "/teams/add-member/123",
+		Hash: teamHash(sirius.Team{}),
 	}, template.lastVars)
 }
 
@@ -94,7 +96,7 @@ func TestGetAddTeamMemberNoPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/add-member/123", nil)
 
-	err := addTeamMember(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := addTeamMember(nil, nil, &mockAuditLogger{})(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -115,7 +117,7 @@ func TestGetAddTeamMemberSearch(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/add-member/123?search=admin", nil)
 
-	err := addTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -134,6 +136,7 @@ func TestGetAddTeamMemberSearch(t *testing.T) {
 		Path:    "/teams/add-member/123",
 		Search:  "admin",
 		Team:    client.team.data,
+		Hash:    teamHash(client.team.data),
 		Users:   client.searchUsers.data,
 		Members: map[int]bool{5: true},
 	}, template.lastVars)
@@ -151,7 +154,7 @@ func TestGetAddTeamMemberTeamError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/add-member/123", nil)
 
-	err := addTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedError, err)
 
 	assert.Equal(1, client.team.count)
@@ -175,7 +178,7 @@ func TestGetAddTeamMemberSearchClientError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/add-member/123?search=admin", nil)
 
-	err := addTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -188,6 +191,7 @@ func TestGetAddTeamMemberSearchClientError(t *testing.T) {
 		Path:   "/teams/add-member/123",
 		Search: "admin",
 		Team:   client.team.data,
+		Hash:   teamHash(client.team.data),
 		Users:  nil,
 		Errors: sirius.ValidationErrors{
 			"search": {
@@ -214,7 +218,7 @@ func TestGetAddTeamMemberSearchError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/add-member/123?search=admin", nil)
 
-	err := addTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedError, err)
 
 	assert.Equal(1, client.team.count)
@@ -234,7 +238,7 @@ func TestGetAddTeamMemberBadPath(t *testing.T) {
 
 			client := &mockAddTeamMemberClient{}
 			r, _ := http.NewRequest("GET", path, nil)
-			err := editTeam(nil, nil)(client.requiredPermissions(), nil, r)
+			err := editTeam(nil, nil, &mockAuditLogger{})(client.requiredPermissions(), nil, r)
 
 			assert.Equal(StatusError(http.StatusNotFound), err)
 		})
@@ -253,10 +257,10 @@ func TestPostAddTeamMember(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/add-member/123", strings.NewReader("id=5&search=admin&email=system.admin@opgtest.com"))
+	r, _ := http.NewRequest("POST", "/teams/add-member/123", strings.NewReader("id=5&search=admin&email=system.admin@opgtest.com&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := addTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -266,7 +270,7 @@ func TestPostAddTeamMember(t *testing.T) {
 	newTeam := sirius.Team{
 		Members: []sirius.TeamMember{
 			{ID: 4},
-			{ID: 5},
+			{ID: 5, Email: "system.admin@opgtest.com"},
 		},
 	}
 
@@ -284,6 +288,7 @@ func TestPostAddTeamMember(t *testing.T) {
 		Path:    "/teams/add-member/123",
 		Search:  "admin",
 		Team:    client.team.data,
+		Hash:    teamHash(newTeam),
 		Users:   client.searchUsers.data,
 		Members: map[int]bool{4: true, 5: true},
 		Success: "system.admin@opgtest.com",
@@ -303,10 +308,10 @@ func TestPostAddTeamMemberClientError(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/add-member/123", strings.NewReader("id=5&search=admin&email=system.admin@opgtest.com"))
+	r, _ := http.NewRequest("POST", "/teams/add-member/123", strings.NewReader("id=5&search=admin&email=system.admin@opgtest.com&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := addTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -319,6 +324,7 @@ func TestPostAddTeamMemberClientError(t *testing.T) {
 		Path:    "/teams/add-member/123",
 		Search:  "admin",
 		Team:    client.team.data,
+		Hash:    teamHash(client.team.data),
 		Users:   client.searchUsers.data,
 		Members: map[int]bool{4: true, 5: true},
 		Errors: sirius.ValidationErrors{
@@ -350,10 +356,10 @@ func TestPostAddTeamMemberValidationError(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/add-member/123", strings.NewReader("id=5&search=admin&email=system.admin@opgtest.com"))
+	r, _ := http.NewRequest("POST", "/teams/add-member/123", strings.NewReader("id=5&search=admin&email=system.admin@opgtest.com&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := addTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -366,6 +372,7 @@ func TestPostAddTeamMemberValidationError(t *testing.T) {
 		Path:    "/teams/add-member/123",
 		Search:  "admin",
 		Team:    client.team.data,
+		Hash:    teamHash(client.team.data),
 		Users:   client.searchUsers.data,
 		Members: map[int]bool{4: true, 5: true},
 		Errors:  validationErrors,
@@ -387,10 +394,10 @@ func TestPostAddTeamMemberOtherError(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/add-member/123", strings.NewReader("id=5&search=admin&email=system.admin@opgtest.com"))
+	r, _ := http.NewRequest("POST", "/teams/add-member/123", strings.NewReader("id=5&search=admin&email=system.admin@opgtest.com&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := addTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedError, err)
 
 	assert.Equal(1, client.team.count)
@@ -406,6 +413,44 @@ func TestPutAddTeamMemberTeam(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("PUT", "/teams/add-member/123", nil)
 
-	err := addTeamMember(nil, nil)(client.requiredPermissions(), w, r)
+	err := addTeamMember(nil, nil, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
 }
+
+func TestPostAddTeamMemberConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockAddTeamMemberClient{}
+	client.team.data = sirius.Team{
+		ID: 123,
+		Members: []sirius.TeamMember{
+			{ID: 4, DisplayName: "Anne"},
+			{ID: 6, DisplayName: "Carl"},
+		},
+	}
+	template := &mockTemplate{}
+
+	seen := sirius.Team{ID: 123, Members: []sirius.TeamMember{{ID: 4}}}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/teams/add-member/123", strings.NewReader("xsrfToken=abcde&id=5&search=admin&email=system.admin@opgtest.com&hash="+teamHash(seen)))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := addTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusConflict, w.Result().StatusCode)
+	assert.Equal(0, client.editTeam.count)
+	assert.Equal(0, client.searchUsers.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal(&teamConflict{
+		Hash: teamHash(client.team.data),
+		Fields: url.Values{
+			"id":     {"5"},
+			"search": {"admin"},
+			"email":  {"system.admin@opgtest.com"},
+		},
+		Joined: []sirius.TeamMember{{ID: 5, Email: "system.admin@opgtest.com"}},
+	}, template.lastVars.(addTeamMemberVars).Conflict)
+}
@@ -0,0 +1,240 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+const maxTeamMemberEmails = 100
+
+type AddTeamMembersClient interface {
+	Team(sirius.Context, int) (sirius.Team, error)
+	EditTeam(sirius.Context, sirius.Team) error
+	SearchUsers(sirius.Context, string) ([]sirius.User, error)
+}
+
+type teamMemberEmailStatus string
+
+const (
+	teamMemberEmailResolved  = teamMemberEmailStatus("resolved")
+	teamMemberEmailMember    = teamMemberEmailStatus("member")
+	teamMemberEmailAmbiguous = teamMemberEmailStatus("ambiguous")
+	teamMemberEmailNotFound  = teamMemberEmailStatus("not-found")
+)
+
+type teamMemberEmail struct {
+	Email   string
+	User    sirius.User
+	Status  teamMemberEmailStatus
+	Matches int
+	Error   string
+}
+
+type addTeamMembersVars struct {
+	Path      string
+	XSRFToken string
+	Team      sirius.Team
+	Hash      string
+	Emails    string
+	Results   []teamMemberEmail
+	Resolved  int
+	Added     int
+	Confirmed bool
+	Conflict  *teamConflict
+	Errors    sirius.ValidationErrors
+}
+
+func addTeamMembers(client AddTeamMembersClient, tmpl Template, audit AuditLogger) Handler {
+	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		if !perm.HasPermission("v1-teams", http.MethodPut) {
+			return StatusError(http.StatusForbidden)
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			return StatusError(http.StatusMethodNotAllowed)
+		}
+
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/teams/add-members/"))
+		if err != nil {
+			return StatusError(http.StatusNotFound)
+		}
+
+		ctx := getContext(r)
+
+		team, err := client.Team(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		vars := addTeamMembersVars{
+			Path:      r.URL.Path,
+			XSRFToken: ctx.XSRFToken,
+			Team:      team,
+			Hash:      teamHash(team),
+		}
+
+		if r.Method == http.MethodGet {
+			return tmpl.ExecuteTemplate(w, "page", vars)
+		}
+
+		vars.Emails = r.PostFormValue("emails")
+		vars.Hash = r.PostFormValue("hash")
+
+		emails := parseTeamMemberEmails(vars.Emails)
+		if len(emails) == 0 {
+			vars.Errors = sirius.ValidationErrors{
+				"emails": {
+					"isEmpty": "Enter at least one email address",
+				},
+			}
+		} else if len(emails) > maxTeamMemberEmails {
+			vars.Errors = sirius.ValidationErrors{
+				"emails": {
+					"tooLong": "Enter no more than " + strconv.Itoa(maxTeamMemberEmails) + " email addresses",
+				},
+			}
+		}
+
+		if vars.Errors != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return tmpl.ExecuteTemplate(w, "page", vars)
+		}
+
+		members := map[int]bool{}
+		for _, member := range team.Members {
+			members[member.ID] = true
+		}
+
+		for _, email := range emails {
+			result, err := resolveTeamMemberEmail(ctx, client, email, members)
+			if err != nil {
+				return err
+			}
+
+			if result.Status == teamMemberEmailResolved {
+				vars.Resolved++
+			}
+
+			vars.Results = append(vars.Results, result)
+		}
+
+		if r.PostFormValue("confirm") != "" && vars.Resolved > 0 {
+			for _, result := range vars.Results {
+				if result.Status == teamMemberEmailResolved {
+					team.Members = append(team.Members, sirius.TeamMember{
+						ID:          result.User.ID,
+						DisplayName: result.User.DisplayName,
+						Email:       result.User.Email,
+					})
+				}
+			}
+
+			if vars.Hash != teamHash(vars.Team) {
+				vars.Conflict = newTeamConflict(r, vars.Team, team)
+				w.WriteHeader(http.StatusConflict)
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
+			err := client.EditTeam(ctx, team)
+
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "add-team-members",
+				TargetType: "team",
+				TargetID:   id,
+				Before:     vars.Team,
+				After:      team,
+				Err:        err,
+			})
+
+			if _, ok := err.(sirius.ClientError); ok {
+				vars.Errors = sirius.ValidationErrors{
+					"": {
+						"": err.Error(),
+					},
+				}
+				w.WriteHeader(http.StatusBadRequest)
+			} else if verr, ok := err.(*sirius.ValidationError); ok {
+				vars.Errors = verr.Errors
+				w.WriteHeader(http.StatusBadRequest)
+			} else if err != nil {
+				return err
+			} else {
+				vars.Team = team
+				vars.Hash = teamHash(team)
+				vars.Added = vars.Resolved
+				vars.Confirmed = true
+			}
+		}
+
+		return tmpl.ExecuteTemplate(w, "page", vars)
+	}
+}
+
+// parseTeamMemberEmails splits a pasted list on whitespace, commas and
+// semicolons, ignoring any email that has already been given.
+func parseTeamMemberEmails(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\r' || r == '\n'
+	})
+
+	seen := map[string]bool{}
+	var emails []string
+
+	for _, field := range fields {
+		key := strings.ToLower(field)
+		if !seen[key] {
+			seen[key] = true
+			emails = append(emails, field)
+		}
+	}
+
+	return emails
+}
+
+func resolveTeamMemberEmail(ctx sirius.Context, client AddTeamMembersClient, email string, members map[int]bool) (teamMemberEmail, error) {
+	result := teamMemberEmail{Email: email}
+
+	users, err := client.SearchUsers(ctx, email)
+	if _, ok := err.(sirius.ClientError); ok && err != sirius.ErrUnauthorized {
+		result.Status = teamMemberEmailNotFound
+		result.Error = err.Error()
+		return result, nil
+	} else if err != nil {
+		return result, err
+	}
+
+	var matches []sirius.User
+	for _, user := range users {
+		if strings.EqualFold(user.Email, email) {
+			matches = append(matches, user)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		if len(users) > 0 {
+			result.Status = teamMemberEmailAmbiguous
+			result.Matches = len(users)
+		} else {
+			result.Status = teamMemberEmailNotFound
+		}
+
+	case 1:
+		result.User = matches[0]
+		result.Status = teamMemberEmailResolved
+
+		if members[result.User.ID] {
+			result.Status = teamMemberEmailMember
+		}
+
+	default:
+		result.Status = teamMemberEmailAmbiguous
+		result.Matches = len(matches)
+	}
+
+	return result, nil
+}
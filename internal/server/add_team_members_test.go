@@ -0,0 +1,392 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAddTeamMembersClient struct {
+	team struct {
+		count   int
+		lastCtx sirius.Context
+		lastID  int
+		data    sirius.Team
+		err     error
+	}
+	editTeam struct {
+		count    int
+		lastCtx  sirius.Context
+		lastTeam sirius.Team
+		err      error
+	}
+	searchUsers struct {
+		count      int
+		lastCtx    sirius.Context
+		lastSearch []string
+		data       map[string][]sirius.User
+		err        map[string]error
+	}
+}
+
+func (c *mockAddTeamMembersClient) Team(ctx sirius.Context, id int) (sirius.Team, error) {
+	c.team.count += 1
+	c.team.lastCtx = ctx
+	c.team.lastID = id
+
+	return c.team.data, c.team.err
+}
+
+func (c *mockAddTeamMembersClient) EditTeam(ctx sirius.Context, team sirius.Team) error {
+	c.editTeam.count += 1
+	c.editTeam.lastCtx = ctx
+	c.editTeam.lastTeam = team
+
+	return c.editTeam.err
+}
+
+func (c *mockAddTeamMembersClient) SearchUsers(ctx sirius.Context, search string) ([]sirius.User, error) {
+	c.searchUsers.count += 1
+	c.searchUsers.lastCtx = ctx
+	c.searchUsers.lastSearch = append(c.searchUsers.lastSearch, search)
+
+	return c.searchUsers.data[search], c.searchUsers.err[search]
+}
+
+func (c *mockAddTeamMembersClient) requiredPermissions() sirius.PermissionSet {
+	return sirius.PermissionSet{"v1-teams": sirius.PermissionGroup{Permissions: []string{"put"}}}
+}
+
+func newMockAddTeamMembersClient() *mockAddTeamMembersClient {
+	client := &mockAddTeamMembersClient{}
+	client.team.data = sirius.Team{
+		ID:          5,
+		DisplayName: "Allocations",
+		Members:     []sirius.TeamMember{{ID: 1, DisplayName: "Anne", Email: "anne@opgtest.com"}},
+	}
+	client.searchUsers.data = map[string][]sirius.User{
+		"anne@opgtest.com": {{ID: 1, DisplayName: "Anne", Email: "anne@opgtest.com"}},
+		"BOB@opgtest.com":  {{ID: 2, DisplayName: "Bob", Email: "bob@opgtest.com"}},
+		"carl@opgtest.com": {{ID: 3, DisplayName: "Carl", Email: "carl@opgtest.com.uk"}, {ID: 4, DisplayName: "Carla", Email: "carl@opgtest.com.au"}},
+		"dee@opgtest.com":  {{ID: 5, DisplayName: "Dee", Email: "dee@opgtest.com"}},
+	}
+	client.searchUsers.err = map[string]error{
+		"ed": sirius.ClientError("Search term must be at least three characters"),
+	}
+
+	return client
+}
+
+func postAddTeamMembers(form url.Values) *http.Request {
+	form.Set("xsrfToken", "abcde")
+
+	r, _ := http.NewRequest("POST", "/teams/add-members/5", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestGetAddTeamMembers(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockAddTeamMembersClient()
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/teams/add-members/5", nil)
+
+	err := addTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(1, client.team.count)
+	assert.Equal(getContext(r), client.team.lastCtx)
+	assert.Equal(5, client.team.lastID)
+	assert.Equal(0, client.searchUsers.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal("page", template.lastName)
+	assert.Equal(addTeamMembersVars{
+		Path: "/teams/add-members/5",
+		Team: client.team.data,
+		Hash: teamHash(client.team.data),
+	}, template.lastVars)
+}
+
+func TestGetAddTeamMembersNoPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/teams/add-members/5", nil)
+
+	err := addTeamMembers(nil, nil, nil)(sirius.PermissionSet{}, w, r)
+	assert.Equal(StatusError(http.StatusForbidden), err)
+}
+
+func TestGetAddTeamMembersBadPath(t *testing.T) {
+	assert := assert.New(t)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/teams/add-members/team", nil)
+
+	client := &mockAddTeamMembersClient{}
+	err := addTeamMembers(client, nil, nil)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusNotFound), err)
+}
+
+func TestPostAddTeamMembersPreview(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockAddTeamMembersClient()
+	template := &mockTemplate{}
+
+	emails := "anne@opgtest.com\nBOB@opgtest.com, bob@opgtest.com;carl@opgtest.com\r\nfrank@opgtest.com ed"
+
+	w := httptest.NewRecorder()
+	r := postAddTeamMembers(url.Values{"emails": {emails}})
+
+	err := addTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusOK, w.Result().StatusCode)
+	assert.Equal([]string{"anne@opgtest.com", "BOB@opgtest.com", "carl@opgtest.com", "frank@opgtest.com", "ed"}, client.searchUsers.lastSearch)
+	assert.Equal(getContext(r), client.searchUsers.lastCtx)
+	assert.Equal(0, client.editTeam.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal(addTeamMembersVars{
+		Path:      "/teams/add-members/5",
+		XSRFToken: "abcde",
+		Team:      client.team.data,
+		Emails:    emails,
+		Resolved:  1,
+		Results: []teamMemberEmail{
+			{Email: "anne@opgtest.com", User: sirius.User{ID: 1, DisplayName: "Anne", Email: "anne@opgtest.com"}, Status: teamMemberEmailMember},
+			{Email: "BOB@opgtest.com", User: sirius.User{ID: 2, DisplayName: "Bob", Email: "bob@opgtest.com"}, Status: teamMemberEmailResolved},
+			{Email: "carl@opgtest.com", Status: teamMemberEmailAmbiguous, Matches: 2},
+			{Email: "frank@opgtest.com", Status: teamMemberEmailNotFound},
+			{Email: "ed", Status: teamMemberEmailNotFound, Error: "Search term must be at least three characters"},
+		},
+	}, template.lastVars)
+}
+
+func TestPostAddTeamMembersNoEmails(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockAddTeamMembersClient()
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r := postAddTeamMembers(url.Values{"emails": {" ,\n"}})
+
+	err := addTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(0, client.searchUsers.count)
+	assert.Equal(sirius.ValidationErrors{
+		"emails": {"isEmpty": "Enter at least one email address"},
+	}, template.lastVars.(addTeamMembersVars).Errors)
+}
+
+func TestPostAddTeamMembersTooMany(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockAddTeamMembersClient()
+	template := &mockTemplate{}
+
+	var emails []string
+	for i := 0; i <= maxTeamMemberEmails; i++ {
+		emails = append(emails, strings.Repeat("a", i+1)+"@opgtest.com")
+	}
+
+	w := httptest.NewRecorder()
+	r := postAddTeamMembers(url.Values{"emails": {strings.Join(emails, "\n")}})
+
+	err := addTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(0, client.searchUsers.count)
+	assert.Equal(sirius.ValidationErrors{
+		"emails": {"tooLong": "Enter no more than 100 email addresses"},
+	}, template.lastVars.(addTeamMembersVars).Errors)
+}
+
+func TestPostAddTeamMembersConfirm(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockAddTeamMembersClient()
+	template := &mockTemplate{}
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r := postAddTeamMembers(url.Values{
+		"emails":  {"anne@opgtest.com\nBOB@opgtest.com\ndee@opgtest.com\nfrank@opgtest.com"},
+		"confirm": {"confirm"},
+		"hash":    {teamHash(client.team.data)},
+	})
+
+	err := addTeamMembers(client, template, audit)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	expectedTeam := sirius.Team{
+		ID:          5,
+		DisplayName: "Allocations",
+		Members: []sirius.TeamMember{
+			{ID: 1, DisplayName: "Anne", Email: "anne@opgtest.com"},
+			{ID: 2, DisplayName: "Bob", Email: "bob@opgtest.com"},
+			{ID: 5, DisplayName: "Dee", Email: "dee@opgtest.com"},
+		},
+	}
+
+	assert.Equal(1, client.editTeam.count)
+	assert.Equal(getContext(r), client.editTeam.lastCtx)
+	assert.Equal(expectedTeam, client.editTeam.lastTeam)
+
+	vars := template.lastVars.(addTeamMembersVars)
+	assert.True(vars.Confirmed)
+	assert.Equal(2, vars.Added)
+	assert.Equal(expectedTeam, vars.Team)
+	assert.Equal(teamHash(expectedTeam), vars.Hash)
+
+	assert.Equal(1, audit.count)
+	assert.Equal(logging.AuditEvent{
+		Action:     "add-team-members",
+		TargetType: "team",
+		TargetID:   5,
+		Before:     client.team.data,
+		After:      expectedTeam,
+	}, audit.lastEvent())
+}
+
+func TestPostAddTeamMembersConfirmNothingToAdd(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockAddTeamMembersClient()
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r := postAddTeamMembers(url.Values{
+		"emails":  {"anne@opgtest.com"},
+		"confirm": {"confirm"},
+		"hash":    {teamHash(client.team.data)},
+	})
+
+	err := addTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(0, client.editTeam.count)
+	assert.False(template.lastVars.(addTeamMembersVars).Confirmed)
+}
+
+func TestPostAddTeamMembersConfirmValidationError(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockAddTeamMembersClient()
+	client.editTeam.err = &sirius.ValidationError{
+		Errors: sirius.ValidationErrors{"members": {"": "problem"}},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r := postAddTeamMembers(url.Values{
+		"emails":  {"BOB@opgtest.com"},
+		"confirm": {"confirm"},
+		"hash":    {teamHash(client.team.data)},
+	})
+
+	err := addTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+
+	vars := template.lastVars.(addTeamMembersVars)
+	assert.False(vars.Confirmed)
+	assert.Equal(client.team.data, vars.Team)
+	assert.Equal(sirius.ValidationErrors{"members": {"": "problem"}}, vars.Errors)
+}
+
+func TestPostAddTeamMembersConfirmConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockAddTeamMembersClient()
+	template := &mockTemplate{}
+
+	seen := client.team.data
+	seen.Members = nil
+
+	w := httptest.NewRecorder()
+	r := postAddTeamMembers(url.Values{
+		"emails":  {"BOB@opgtest.com"},
+		"confirm": {"confirm"},
+		"hash":    {teamHash(seen)},
+	})
+
+	err := addTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusConflict, w.Result().StatusCode)
+	assert.Equal(0, client.editTeam.count)
+
+	vars := template.lastVars.(addTeamMembersVars)
+	assert.False(vars.Confirmed)
+	assert.Equal(&teamConflict{
+		Hash: teamHash(client.team.data),
+		Fields: url.Values{
+			"emails":  {"BOB@opgtest.com"},
+			"confirm": {"confirm"},
+		},
+		Joined: []sirius.TeamMember{{ID: 2, DisplayName: "Bob", Email: "bob@opgtest.com"}},
+	}, vars.Conflict)
+}
+
+func TestPostAddTeamMembersErrors(t *testing.T) {
+	expectedErr := errors.New("oops")
+
+	for name, tc := range map[string]struct {
+		setup func(*mockAddTeamMembersClient)
+		err   error
+	}{
+		"Team":         {func(c *mockAddTeamMembersClient) { c.team.err = expectedErr }, expectedErr},
+		"SearchUsers":  {func(c *mockAddTeamMembersClient) { c.searchUsers.err["BOB@opgtest.com"] = expectedErr }, expectedErr},
+		"EditTeam":     {func(c *mockAddTeamMembersClient) { c.editTeam.err = expectedErr }, expectedErr},
+		"Unauthorized": {func(c *mockAddTeamMembersClient) { c.searchUsers.err["BOB@opgtest.com"] = sirius.ErrUnauthorized }, sirius.ErrUnauthorized},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := newMockAddTeamMembersClient()
+			tc.setup(client)
+			template := &mockTemplate{}
+
+			w := httptest.NewRecorder()
+			r := postAddTeamMembers(url.Values{
+				"emails":  {"BOB@opgtest.com"},
+				"confirm": {"confirm"},
+				"hash":    {teamHash(client.team.data)},
+			})
+
+			err := addTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+			assert.Equal(tc.err, err)
+
+			assert.Equal(0, template.count)
+		})
+	}
+}
+
+func TestPutAddTeamMembers(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockAddTeamMembersClient{}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", "/teams/add-members/5", nil)
+
+	err := addTeamMembers(nil, nil, nil)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
+}
@@ -64,7 +64,7 @@ func TestGetAddTeam(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := addTeam(client, template)(client.requiredPermissions(), w, r)
+	err := addTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(0, client.addTeam.count)
@@ -86,7 +86,7 @@ func TestGetAddTeamNoPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := addTeam(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := addTeam(nil, nil, &mockAuditLogger{})(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -102,7 +102,7 @@ func TestGetAddTeamTeamTypesError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := addTeam(client, template)(client.requiredPermissions(), w, r)
+	err := addTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedError, err)
 
 	assert.Equal(1, client.teamTypes.count)
@@ -124,7 +124,7 @@ func TestPostAddTeam(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("name=a&service=b&supervision-type=c&phone=d&email=e"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := addTeam(client, template)(client.requiredPermissions(), w, r)
+	err := addTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(RedirectError("/teams/123"), err)
 
 	assert.Equal(1, client.addTeam.count)
@@ -152,7 +152,7 @@ func TestPostAddTeamLpa(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("name=a&service=lpa&supervision-type=c&phone=d&email=e"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := addTeam(client, template)(client.requiredPermissions(), w, r)
+	err := addTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(RedirectError("/teams/123"), err)
 
 	assert.Equal(1, client.addTeam.count)
@@ -184,7 +184,7 @@ func TestPostAddTeamValidationError(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("name=a&service=b&supervision-type=c&phone=d&email=e"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := addTeam(client, template)(client.requiredPermissions(), w, r)
+	err := addTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.addTeam.count)
@@ -222,7 +222,7 @@ func TestPostAddTeamError(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("name=a&service=b&supervision-type=c&phone=d&email=e"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := addTeam(client, template)(client.requiredPermissions(), w, r)
+	err := addTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedError, err)
 
 	assert.Equal(1, client.addTeam.count)
@@ -237,6 +237,6 @@ func TestPutAddTeam(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("PUT", "/path", nil)
 
-	err := addTeam(client, nil)(client.requiredPermissions(), w, r)
+	err := addTeam(client, nil, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
 }
@@ -3,23 +3,26 @@ package server
 import (
 	"net/http"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
 type AddUserClient interface {
-	AddUser(ctx sirius.Context, email, firstname, surname, organisation string, roles []string) error
+	AddUser(ctx sirius.Context, email, firstname, surname string, organisation sirius.Organisation, roles []string) error
+	Organisations() []sirius.Organisation
 	Roles(sirius.Context) ([]string, error)
 }
 
 type addUserVars struct {
-	Path      string
-	XSRFToken string
-	Roles     []string
-	Success   bool
-	Errors    sirius.ValidationErrors
+	Path          string
+	XSRFToken     string
+	Organisations []sirius.Organisation
+	Roles         []string
+	Success       bool
+	Errors        sirius.ValidationErrors
 }
 
-func addUser(client AddUserClient, tmpl Template) Handler {
+func addUser(client AddUserClient, tmpl Template, audit AuditLogger, policy RolePolicy) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-users", http.MethodPost) {
 			return StatusError(http.StatusForbidden)
@@ -33,9 +36,10 @@ func addUser(client AddUserClient, tmpl Template) Handler {
 		}
 
 		vars := addUserVars{
-			Path:      r.URL.Path,
-			XSRFToken: ctx.XSRFToken,
-			Roles:     roles,
+			Path:          r.URL.Path,
+			XSRFToken:     ctx.XSRFToken,
+			Organisations: client.Organisations(),
+			Roles:         roles,
 		}
 
 		switch r.Method {
@@ -47,12 +51,32 @@ func addUser(client AddUserClient, tmpl Template) Handler {
 				email        = r.PostFormValue("email")
 				firstname    = r.PostFormValue("firstname")
 				surname      = r.PostFormValue("surname")
-				organisation = r.PostFormValue("organisation")
+				organisation = sirius.Organisation(r.PostFormValue("organisation"))
 				roles        = r.PostForm["roles"]
 			)
 
+			if violations := policy.Check(organisation, roles); violations != nil {
+				vars.Errors = sirius.ValidationErrors{"roles": violations}
+
+				w.WriteHeader(http.StatusBadRequest)
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
 			err := client.AddUser(ctx, email, firstname, surname, organisation, roles)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "add-user",
+				TargetType: "user",
+				After: sirius.AuthUser{
+					Email:        email,
+					Firstname:    firstname,
+					Surname:      surname,
+					Organisation: organisation,
+					Roles:        roles,
+				},
+				Err: err,
+			})
+
 			if verr, ok := err.(sirius.ValidationError); ok {
 				vars.Errors = verr.Errors
 
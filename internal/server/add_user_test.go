@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,7 +19,7 @@ type mockAddUserClient struct {
 		lastEmail        string
 		lastFirstname    string
 		lastSurname      string
-		lastOrganisation string
+		lastOrganisation sirius.Organisation
 		lastRoles        []string
 		err              error
 	}
@@ -30,7 +31,7 @@ type mockAddUserClient struct {
 	}
 }
 
-func (m *mockAddUserClient) AddUser(ctx sirius.Context, email, firstname, surname, organisation string, roles []string) error {
+func (m *mockAddUserClient) AddUser(ctx sirius.Context, email, firstname, surname string, organisation sirius.Organisation, roles []string) error {
 	m.addUser.count += 1
 	m.addUser.lastCtx = ctx
 	m.addUser.lastEmail = email
@@ -49,6 +50,25 @@ func (m *mockAddUserClient) Roles(ctx sirius.Context) ([]string, error) {
 	return []string{"System Admin", "Manager"}, m.roles.err
 }
 
+type mockRolePolicy struct {
+	count            int
+	lastOrganisation sirius.Organisation
+	lastRoles        []string
+	data             map[string]string
+}
+
+func (m *mockRolePolicy) Check(organisation sirius.Organisation, roles []string) map[string]string {
+	m.count += 1
+	m.lastOrganisation = organisation
+	m.lastRoles = roles
+
+	return m.data
+}
+
+func (m *mockAddUserClient) Organisations() []sirius.Organisation {
+	return []sirius.Organisation{"OPG User", "COP User"}
+}
+
 func (m *mockAddUserClient) requiredPermissions() sirius.PermissionSet {
 	return sirius.PermissionSet{"v1-users": sirius.PermissionGroup{Permissions: []string{"post"}}}
 }
@@ -62,7 +82,7 @@ func TestGetAddUser(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := addUser(client, template)(client.requiredPermissions(), w, r)
+	err := addUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.roles.count)
@@ -73,8 +93,9 @@ func TestGetAddUser(t *testing.T) {
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(addUserVars{
-		Path:  "/path",
-		Roles: []string{"System Admin", "Manager"},
+		Path:          "/path",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Roles:         []string{"System Admin", "Manager"},
 	}, template.lastVars)
 }
 
@@ -84,7 +105,7 @@ func TestGetAddUserNoPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := addUser(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := addUser(nil, nil, &mockAuditLogger{}, &mockRolePolicy{})(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -97,27 +118,47 @@ func TestPostAddUser(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("email=a&firstname=b&surname=c&organisation=d&roles=e&roles=f"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	audit := &mockAuditLogger{}
+	policy := &mockRolePolicy{}
 
-	err := addUser(client, template)(client.requiredPermissions(), w, r)
+	err := addUser(client, template, audit, policy)(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.roles.count)
 
+	assert.Equal(1, policy.count)
+	assert.Equal(sirius.Organisation("d"), policy.lastOrganisation)
+	assert.Equal([]string{"e", "f"}, policy.lastRoles)
+
 	assert.Equal(1, client.addUser.count)
 	assert.Equal(getContext(r), client.addUser.lastCtx)
 	assert.Equal("a", client.addUser.lastEmail)
 	assert.Equal("b", client.addUser.lastFirstname)
 	assert.Equal("c", client.addUser.lastSurname)
-	assert.Equal("d", client.addUser.lastOrganisation)
+	assert.Equal(sirius.Organisation("d"), client.addUser.lastOrganisation)
 	assert.Equal([]string{"e", "f"}, client.addUser.lastRoles)
 
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(addUserVars{
-		Path:    "/path",
-		Success: true,
-		Roles:   []string{"System Admin", "Manager"},
+		Path:          "/path",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Success:       true,
+		Roles:         []string{"System Admin", "Manager"},
 	}, template.lastVars)
+
+	assert.Equal(1, audit.count)
+	assert.Equal(logging.AuditEvent{
+		Action:     "add-user",
+		TargetType: "user",
+		After: sirius.AuthUser{
+			Email:        "a",
+			Firstname:    "b",
+			Surname:      "c",
+			Organisation: "d",
+			Roles:        []string{"e", "f"},
+		},
+	}, audit.lastEvent())
 }
 
 func TestPostAddUserValidationError(t *testing.T) {
@@ -137,7 +178,7 @@ func TestPostAddUserValidationError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", nil)
 
-	err := addUser(client, template)(client.requiredPermissions(), w, r)
+	err := addUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	resp := w.Result()
@@ -149,9 +190,41 @@ func TestPostAddUserValidationError(t *testing.T) {
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(addUserVars{
-		Path:   "/path",
-		Roles:  []string{"System Admin", "Manager"},
-		Errors: errors,
+		Path:          "/path",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Roles:         []string{"System Admin", "Manager"},
+		Errors:        errors,
+	}, template.lastVars)
+}
+
+func TestPostAddUserRolePolicyViolation(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockAddUserClient{}
+	template := &mockTemplate{}
+	policy := &mockRolePolicy{}
+	policy.data = map[string]string{"requires": "e can only be given with g"}
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/path", strings.NewReader("email=a&firstname=b&surname=c&organisation=d&roles=e&roles=f"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := addUser(client, template, audit, policy)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(0, client.addUser.count)
+	assert.Equal(0, audit.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal(addUserVars{
+		Path:          "/path",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Roles:         []string{"System Admin", "Manager"},
+		Errors: sirius.ValidationErrors{
+			"roles": {"requires": "e can only be given with g"},
+		},
 	}, template.lastVars)
 }
 
@@ -166,7 +239,7 @@ func TestPostAddUserOtherError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", nil)
 
-	err := addUser(client, template)(client.requiredPermissions(), w, r)
+	err := addUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedErr, err)
 
 	assert.Equal(1, client.roles.count)
@@ -185,7 +258,7 @@ func TestPostAddUserRolesError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", nil)
 
-	err := addUser(client, template)(client.requiredPermissions(), w, r)
+	err := addUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedErr, err)
 
 	assert.Equal(1, client.roles.count)
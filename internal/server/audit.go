@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+type AuditLogger interface {
+	Audit(*http.Request, logging.AuditEvent)
+}
+
+type AuditClient interface {
+	MyDetails(sirius.Context) (sirius.MyDetails, error)
+}
+
+type currentUserClient interface {
+	CurrentUser(sirius.Context) (sirius.MyDetails, error)
+}
+
+type requestActorKey struct{}
+
+// requestActor is kept on the context of a request so that the user making it
+// is only fetched once, however many events are audited.
+type requestActor struct {
+	once      sync.Once
+	myDetails sirius.MyDetails
+	err       error
+}
+
+func withRequestActor(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), requestActorKey{}, &requestActor{}))
+}
+
+// currentUser calls fetch for the user making r, or reuses the result of an
+// earlier call for the same request.
+func currentUser(r *http.Request, fetch func(sirius.Context) (sirius.MyDetails, error)) (sirius.MyDetails, error) {
+	actor, ok := r.Context().Value(requestActorKey{}).(*requestActor)
+	if !ok {
+		return fetch(getContext(r))
+	}
+
+	actor.once.Do(func() {
+		actor.myDetails, actor.err = fetch(getContext(r))
+	})
+
+	return actor.myDetails, actor.err
+}
+
+// actorAuditor fills in the ID and name of the user making the request before passing
+// the event on. An event is still logged if their details cannot be fetched.
+type actorAuditor struct {
+	logger AuditLogger
+	client AuditClient
+}
+
+func (a actorAuditor) Audit(r *http.Request, event logging.AuditEvent) {
+	fetch := a.client.MyDetails
+	if client, ok := a.client.(currentUserClient); ok {
+		fetch = client.CurrentUser
+	}
+
+	if myDetails, err := currentUser(r, fetch); err == nil {
+		event.ActorID = myDetails.ID
+		event.ActorName = myDetails.DisplayName
+	}
+
+	a.logger.Audit(r, event)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorAuditor(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockMyDetailsClient{}
+	client.data = sirius.MyDetails{ID: 47, DisplayName: "Anne Admin"}
+	logger := &mockAuditLogger{}
+
+	r, _ := http.NewRequest("POST", "/path", nil)
+
+	actorAuditor{logger: logger, client: client}.Audit(r, logging.AuditEvent{Action: "delete-user", TargetID: 5})
+
+	assert.Equal(1, client.count)
+	assert.Equal(getContext(r), client.lastCtx)
+
+	assert.Equal(1, logger.count)
+	assert.Equal(r, logger.lastRequest)
+	assert.Equal(logging.AuditEvent{ActorID: 47, ActorName: "Anne Admin", Action: "delete-user", TargetID: 5}, logger.lastEvent())
+}
+
+func TestActorAuditorMyDetailsError(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockMyDetailsClient{}
+	client.err = errors.New("oops")
+	logger := &mockAuditLogger{}
+
+	r, _ := http.NewRequest("POST", "/path", nil)
+
+	actorAuditor{logger: logger, client: client}.Audit(r, logging.AuditEvent{Action: "delete-user", TargetID: 5})
+
+	assert.Equal(1, logger.count)
+	assert.Equal(logging.AuditEvent{Action: "delete-user", TargetID: 5}, logger.lastEvent())
+}
+
+func TestActorAuditorOncePerRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockMyDetailsClient{}
+	client.data = sirius.MyDetails{ID: 47, DisplayName: "Anne Admin"}
+	logger := &mockAuditLogger{}
+	auditor := actorAuditor{logger: logger, client: client}
+
+	r, _ := http.NewRequest("POST", "/path", nil)
+	r = withRequestActor(r)
+
+	for i := 0; i < 3; i++ {
+		auditor.Audit(r, logging.AuditEvent{Action: "import-users"})
+	}
+
+	assert.Equal(1, client.count)
+	assert.Equal(3, logger.count)
+	assert.Equal(47, logger.lastEvent().ActorID)
+
+	other, _ := http.NewRequest("POST", "/path", nil)
+	auditor.Audit(withRequestActor(other), logging.AuditEvent{Action: "import-users"})
+
+	assert.Equal(2, client.count)
+}
+
+func TestActorAuditorUsesCurrentUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	client.myDetails.data = sirius.MyDetails{ID: 47, DisplayName: "Anne Admin"}
+	cache := newPermissionCache(client, time.Minute)
+	logger := &mockAuditLogger{}
+	auditor := actorAuditor{logger: logger, client: cache}
+
+	for i := 0; i < 3; i++ {
+		r, _ := http.NewRequest("POST", "/path", nil)
+		auditor.Audit(withRequestActor(r), logging.AuditEvent{Action: "delete-user"})
+	}
+
+	assert.Equal(1, client.myDetails.count)
+	assert.Equal(logging.AuditEvent{ActorID: 47, ActorName: "Anne Admin", Action: "delete-user"}, logger.lastEvent())
+}
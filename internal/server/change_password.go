@@ -3,6 +3,7 @@ package server
 import (
 	"net/http"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -17,7 +18,7 @@ type changePasswordVars struct {
 	Errors    sirius.ValidationErrors
 }
 
-func changePassword(client ChangePasswordClient, tmpl Template) Handler {
+func changePassword(client ChangePasswordClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		ctx := getContext(r)
 
@@ -39,6 +40,11 @@ func changePassword(client ChangePasswordClient, tmpl Template) Handler {
 
 			err := client.ChangePassword(ctx, currentPassword, password1, password2)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action: "change-password",
+				Err:    err,
+			})
+
 			if err == sirius.ErrUnauthorized {
 				return err
 			}
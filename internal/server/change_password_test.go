@@ -38,7 +38,7 @@ func TestGetChangePassword(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	handler := changePassword(nil, template)
+	handler := changePassword(nil, template, &mockAuditLogger{})
 	err := handler(sirius.PermissionSet{}, w, r)
 
 	assert.Nil(err)
@@ -60,7 +60,7 @@ func TestPostChangePassword(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("currentpassword=a&password1=b&password2=c"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	handler := changePassword(client, template)
+	handler := changePassword(client, template, &mockAuditLogger{})
 	err := handler(sirius.PermissionSet{}, w, r)
 
 	assert.Nil(err)
@@ -86,7 +86,7 @@ func TestPostChangePasswordUnauthenticated(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", nil)
 
-	handler := changePassword(client, template)
+	handler := changePassword(client, template, &mockAuditLogger{})
 	err := handler(sirius.PermissionSet{}, w, r)
 
 	assert.Equal(sirius.ErrUnauthorized, err)
@@ -103,7 +103,7 @@ func TestPostChangePasswordSiriusError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", nil)
 
-	handler := changePassword(client, template)
+	handler := changePassword(client, template, &mockAuditLogger{})
 	err := handler(sirius.PermissionSet{}, w, r)
 
 	assert.Nil(err)
@@ -133,7 +133,7 @@ func TestPostChangePasswordOtherError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", nil)
 
-	handler := changePassword(client, template)
+	handler := changePassword(client, template, &mockAuditLogger{})
 	err := handler(sirius.PermissionSet{}, w, r)
 
 	assert.Equal(expectedErr, err)
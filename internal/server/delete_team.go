@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -22,7 +23,7 @@ type deleteTeamVars struct {
 	SuccessMessage string
 }
 
-func deleteTeam(client DeleteTeamClient, tmpl Template) Handler {
+func deleteTeam(client DeleteTeamClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-teams", http.MethodDelete) {
 			return StatusError(http.StatusForbidden)
@@ -53,6 +54,14 @@ func deleteTeam(client DeleteTeamClient, tmpl Template) Handler {
 		if r.Method == http.MethodPost {
 			err := client.DeleteTeam(ctx, id)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "delete-team",
+				TargetType: "team",
+				TargetID:   id,
+				Before:     team,
+				Err:        err,
+			})
+
 			if _, ok := err.(sirius.ClientError); ok {
 				vars.Errors = sirius.ValidationErrors{
 					"": {
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 	"github.com/stretchr/testify/assert"
 )
@@ -57,7 +58,7 @@ func TestGetDeleteTeam(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/delete/461", nil)
 
-	err := deleteTeam(client, template)(client.requiredPermissions(), w, r)
+	err := deleteTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -78,7 +79,7 @@ func TestGetDeleteTeamNoPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := deleteTeam(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := deleteTeam(nil, nil, &mockAuditLogger{})(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -94,7 +95,7 @@ func TestGetDeleteTeamError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/delete/461", nil)
 
-	err := deleteTeam(client, template)(client.requiredPermissions(), w, r)
+	err := deleteTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedError, err)
 
 	assert.Equal(1, client.team.count)
@@ -117,7 +118,7 @@ func TestGetDeleteTeamBadPath(t *testing.T) {
 			w := httptest.NewRecorder()
 			r, _ := http.NewRequest("GET", path, nil)
 
-			err := deleteTeam(client, template)(client.requiredPermissions(), w, r)
+			err := deleteTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 			assert.Equal(StatusError(http.StatusNotFound), err)
 
 			assert.Equal(0, client.team.count)
@@ -136,8 +137,9 @@ func TestPostDeleteTeam(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/teams/delete/461", nil)
+	audit := &mockAuditLogger{}
 
-	err := deleteTeam(client, template)(client.requiredPermissions(), w, r)
+	err := deleteTeam(client, template, audit)(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -151,6 +153,14 @@ func TestPostDeleteTeam(t *testing.T) {
 		Team:           client.team.data,
 		SuccessMessage: "The team \"Filing - Pool 5\" was deleted.",
 	}, template.lastVars)
+
+	assert.Equal(1, audit.count)
+	assert.Equal(logging.AuditEvent{
+		Action:     "delete-team",
+		TargetType: "team",
+		TargetID:   461,
+		Before:     client.team.data,
+	}, audit.lastEvent())
 }
 
 func TestPostDeleteTeamClientError(t *testing.T) {
@@ -163,7 +173,7 @@ func TestPostDeleteTeamClientError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/teams/delete/461", nil)
 
-	err := deleteTeam(client, template)(client.requiredPermissions(), w, r)
+	err := deleteTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -193,7 +203,7 @@ func TestPostDeleteTeamOtherError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/teams/delete/461", nil)
 
-	err := deleteTeam(client, template)(client.requiredPermissions(), w, r)
+	err := deleteTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedErr, err)
 
 	assert.Equal(1, client.team.count)
@@ -208,6 +218,6 @@ func TestPutDeleteTeam(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("PUT", "/teams/delete/461", nil)
 
-	err := deleteTeam(nil, nil)(client.requiredPermissions(), w, r)
+	err := deleteTeam(nil, nil, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
 }
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -22,7 +23,7 @@ type deleteUserVars struct {
 	SuccessMessage string
 }
 
-func deleteUser(client DeleteUserClient, tmpl Template) Handler {
+func deleteUser(client DeleteUserClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 
         if !perm.HasPermission("v1-users", http.MethodDelete) {
@@ -55,6 +56,14 @@ func deleteUser(client DeleteUserClient, tmpl Template) Handler {
 		if r.Method == http.MethodPost {
 			err := client.DeleteUser(ctx, id)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "delete-user",
+				TargetType: "user",
+				TargetID:   id,
+				Before:     user,
+				Err:        err,
+			})
+
 			if _, ok := err.(sirius.ClientError); ok {
 				vars.Errors = sirius.ValidationErrors{
 					"": {
@@ -3,6 +3,7 @@ package server
 import (
 	"net/http"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -19,7 +20,7 @@ type editMyDetailsVars struct {
 	PhoneNumber string
 }
 
-func editMyDetails(client EditMyDetailsClient, tmpl Template) Handler {
+func editMyDetails(client EditMyDetailsClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-users-updatetelephonenumber", http.MethodPut) {
 			return StatusError(http.StatusForbidden)
@@ -46,6 +47,15 @@ func editMyDetails(client EditMyDetailsClient, tmpl Template) Handler {
 			vars.PhoneNumber = r.FormValue("phonenumber")
 			err := client.EditMyDetails(ctx, myDetails.ID, vars.PhoneNumber)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "edit-my-details",
+				TargetType: "user",
+				TargetID:   myDetails.ID,
+				Before:     map[string]string{"phoneNumber": myDetails.PhoneNumber},
+				After:      map[string]string{"phoneNumber": vars.PhoneNumber},
+				Err:        err,
+			})
+
 			if e, ok := err.(*sirius.ValidationError); ok {
 				vars.Errors = e.Errors
 				w.WriteHeader(http.StatusBadRequest)
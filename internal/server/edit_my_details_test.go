@@ -67,7 +67,7 @@ func TestGetEditMyDetails(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	handler := editMyDetails(client, template)
+	handler := editMyDetails(client, template, &mockAuditLogger{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Nil(err)
@@ -93,7 +93,7 @@ func TestGetEditMyDetailsUnauthenticated(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "", nil)
 
-	handler := editMyDetails(client, template)
+	handler := editMyDetails(client, template, &mockAuditLogger{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Equal(sirius.ErrUnauthorized, err)
@@ -110,7 +110,7 @@ func TestGetEditMyDetailsNotPermitted(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "", nil)
 
-	handler := editMyDetails(client, template)
+	handler := editMyDetails(client, template, &mockAuditLogger{})
 	err := handler(sirius.PermissionSet{}, w, r)
 
 	assert.Equal(StatusError(http.StatusForbidden), err)
@@ -127,7 +127,7 @@ func TestGetEditMyDetailsSiriusErrors(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "", nil)
 
-	handler := editMyDetails(client, template)
+	handler := editMyDetails(client, template, &mockAuditLogger{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Equal("err", err.Error())
@@ -150,7 +150,7 @@ func TestPostEditMyDetails(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("phonenumber=0189202"))
 	r.Header.Add("Content-type", "application/x-www-form-urlencoded")
 
-	handler := editMyDetails(client, template)
+	handler := editMyDetails(client, template, &mockAuditLogger{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Nil(err)
@@ -181,7 +181,7 @@ func TestPostEditMyDetailsUnauthenticated(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("phonenumber=0189202"))
 
-	handler := editMyDetails(client, template)
+	handler := editMyDetails(client, template, &mockAuditLogger{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Equal(sirius.ErrUnauthorized, err)
@@ -202,7 +202,7 @@ func TestPostEditMyDetailsSiriusErrors(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("phonenumber=0189202"))
 	r.Header.Add("Content-type", "application/x-www-form-urlencoded")
 
-	handler := editMyDetails(client, template)
+	handler := editMyDetails(client, template, &mockAuditLogger{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Equal("err", err.Error())
@@ -231,7 +231,7 @@ func TestPostEditMyDetailsInvalidRequest(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("phonenumber=invalid+phone+number"))
 	r.Header.Add("Content-type", "application/x-www-form-urlencoded")
 
-	handler := editMyDetails(client, template)
+	handler := editMyDetails(client, template, &mockAuditLogger{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Nil(err)
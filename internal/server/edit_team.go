@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -18,14 +19,16 @@ type editTeamVars struct {
 	Path            string
 	XSRFToken       string
 	Team            sirius.Team
+	Hash            string
 	TeamTypeOptions []sirius.RefDataTeamType
 	CanEditTeamType bool
 	CanDeleteTeam   bool
 	Success         bool
+	Conflict        *teamConflict
 	Errors          sirius.ValidationErrors
 }
 
-func editTeam(client EditTeamClient, tmpl Template) Handler {
+func editTeam(client EditTeamClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-teams", http.MethodPut) {
 			return StatusError(http.StatusForbidden)
@@ -55,6 +58,7 @@ func editTeam(client EditTeamClient, tmpl Template) Handler {
 			Path:            r.URL.Path,
 			XSRFToken:       ctx.XSRFToken,
 			Team:            team,
+			Hash:            teamHash(team),
 			TeamTypeOptions: teamTypes,
 			CanEditTeamType: canEditTeamType,
 			CanDeleteTeam:   canDeleteTeam,
@@ -78,15 +82,32 @@ func editTeam(client EditTeamClient, tmpl Template) Handler {
 				vars.Team.Type = team.Type
 			}
 
+			if r.PostFormValue("hash") != vars.Hash {
+				vars.Conflict = newTeamConflict(r, team, vars.Team)
+				vars.Team = team
+				w.WriteHeader(http.StatusConflict)
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
 			// Attempt to save
 			err := client.EditTeam(ctx, vars.Team)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "edit-team",
+				TargetType: "team",
+				TargetID:   id,
+				Before:     team,
+				After:      vars.Team,
+				Err:        err,
+			})
+
 			if e, ok := err.(*sirius.ValidationError); ok {
 				vars.Errors = e.Errors
 				w.WriteHeader(http.StatusBadRequest)
 			} else if err != nil {
 				return err
 			} else {
+				vars.Hash = teamHash(vars.Team)
 				vars.Success = true
 			}
 
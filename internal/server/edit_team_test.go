@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -80,7 +81,7 @@ func TestGetEditTeam(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/edit/123", nil)
 
-	err := editTeam(client, template)(client.requiredPermissions(), w, r)
+	err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -95,6 +96,7 @@ func TestGetEditTeam(t *testing.T) {
 	assert.Equal(editTeamVars{
 		Path:            "/teams/edit/123",
 		Team:            client.team.data,
+		Hash:            teamHash(client.team.data),
 		TeamTypeOptions: client.teamTypes.data,
 		CanEditTeamType: true,
 	}, template.lastVars)
@@ -106,7 +108,7 @@ func TestGetEditTeamNoPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := editTeam(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := editTeam(nil, nil, &mockAuditLogger{})(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -126,7 +128,7 @@ func TestGetEditTeamWithoutTypeEditPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/edit/123", nil)
 
-	err := editTeam(client, template)(sirius.PermissionSet{
+	err := editTeam(client, template, &mockAuditLogger{})(sirius.PermissionSet{
 		"v1-teams": sirius.PermissionGroup{Permissions: []string{"put"}},
 	}, w, r)
 	assert.Nil(err)
@@ -134,6 +136,7 @@ func TestGetEditTeamWithoutTypeEditPermission(t *testing.T) {
 	assert.Equal(editTeamVars{
 		Path:            "/teams/edit/123",
 		Team:            client.team.data,
+		Hash:            teamHash(client.team.data),
 		TeamTypeOptions: client.teamTypes.data,
 	}, template.lastVars)
 }
@@ -158,12 +161,13 @@ func TestGetEditTeamWithDeletePermission(t *testing.T) {
 		"v1-teams": sirius.PermissionGroup{Permissions: []string{"put", "post", "delete"}},
 	}
 
-	err := editTeam(client, template)(permissions, w, r)
+	err := editTeam(client, template, &mockAuditLogger{})(permissions, w, r)
 	assert.Nil(err)
 
 	assert.Equal(editTeamVars{
 		Path:            "/teams/edit/123",
 		Team:            client.team.data,
+		Hash:            teamHash(client.team.data),
 		TeamTypeOptions: client.teamTypes.data,
 		CanEditTeamType: true,
 		CanDeleteTeam:   true,
@@ -185,7 +189,7 @@ func TestGetEditTeamBadPath(t *testing.T) {
 
 			r, _ := http.NewRequest("GET", path, nil)
 
-			err := editTeam(client, template)(client.requiredPermissions(), nil, r)
+			err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), nil, r)
 
 			assert.Equal(StatusError(http.StatusNotFound), err)
 
@@ -211,10 +215,10 @@ func TestPostEditTeam(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("name=New+name&service=supervision&supervision-type=FINANCE&email=new@opgtest.com&phone=9876"))
+	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("name=New+name&service=supervision&supervision-type=FINANCE&email=new@opgtest.com&phone=9876&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-type", "application/x-www-form-urlencoded")
 
-	err := editTeam(client, template)(client.requiredPermissions(), w, r)
+	err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -223,17 +227,20 @@ func TestPostEditTeam(t *testing.T) {
 	assert.Equal(123, client.editTeam.lastTeam.ID)
 	assert.Equal("New name", client.editTeam.lastTeam.DisplayName)
 
+	expectedTeam := sirius.Team{
+		ID:          123,
+		DisplayName: "New name",
+		Type:        "FINANCE",
+		Email:       "new@opgtest.com",
+		PhoneNumber: "9876",
+	}
+
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(editTeamVars{
-		Path: "/teams/edit/123",
-		Team: sirius.Team{
-			ID:          123,
-			DisplayName: "New name",
-			Type:        "FINANCE",
-			Email:       "new@opgtest.com",
-			PhoneNumber: "9876",
-		},
+		Path:            "/teams/edit/123",
+		Team:            expectedTeam,
+		Hash:            teamHash(expectedTeam),
 		TeamTypeOptions: client.teamTypes.data,
 		CanEditTeamType: true,
 		Success:         true,
@@ -254,10 +261,10 @@ func TestPostEditLpaTeam(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("name=New+name&service=lpa&email=new@opgtest.com&phone=9876"))
+	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("name=New+name&service=lpa&email=new@opgtest.com&phone=9876&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-type", "application/x-www-form-urlencoded")
 
-	err := editTeam(client, template)(client.requiredPermissions(), w, r)
+	err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -266,17 +273,20 @@ func TestPostEditLpaTeam(t *testing.T) {
 	assert.Equal(123, client.editTeam.lastTeam.ID)
 	assert.Equal("New name", client.editTeam.lastTeam.DisplayName)
 
+	expectedTeam := sirius.Team{
+		ID:          123,
+		DisplayName: "New name",
+		Type:        "",
+		Email:       "new@opgtest.com",
+		PhoneNumber: "9876",
+	}
+
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(editTeamVars{
-		Path: "/teams/edit/123",
-		Team: sirius.Team{
-			ID:          123,
-			DisplayName: "New name",
-			Type:        "",
-			Email:       "new@opgtest.com",
-			PhoneNumber: "9876",
-		},
+		Path:            "/teams/edit/123",
+		Team:            expectedTeam,
+		Hash:            teamHash(expectedTeam),
 		TeamTypeOptions: client.teamTypes.data,
 		CanEditTeamType: true,
 		Success:         true,
@@ -298,10 +308,10 @@ func TestPostEditTeamWithoutPermission(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("name=New+name&service=lpa&email=new@opgtest.com&phone=9876"))
+	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("name=New+name&service=lpa&email=new@opgtest.com&phone=9876&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-type", "application/x-www-form-urlencoded")
 
-	err := editTeam(client, template)(sirius.PermissionSet{
+	err := editTeam(client, template, &mockAuditLogger{})(sirius.PermissionSet{
 		"v1-teams": sirius.PermissionGroup{Permissions: []string{"put"}},
 	}, w, r)
 	assert.Nil(err)
@@ -309,15 +319,18 @@ func TestPostEditTeamWithoutPermission(t *testing.T) {
 	assert.Equal(1, client.editTeam.count)
 	assert.Equal("COMPLAINTS", client.editTeam.lastTeam.Type)
 
+	expectedTeam := sirius.Team{
+		ID:          123,
+		DisplayName: "New name",
+		Type:        "COMPLAINTS",
+		Email:       "new@opgtest.com",
+		PhoneNumber: "9876",
+	}
+
 	assert.Equal(editTeamVars{
-		Path: "/teams/edit/123",
-		Team: sirius.Team{
-			ID:          123,
-			DisplayName: "New name",
-			Type:        "COMPLAINTS",
-			Email:       "new@opgtest.com",
-			PhoneNumber: "9876",
-		},
+		Path:            "/teams/edit/123",
+		Team:            expectedTeam,
+		Hash:            teamHash(expectedTeam),
 		TeamTypeOptions: client.teamTypes.data,
 		Success:         true,
 	}, template.lastVars)
@@ -346,31 +359,78 @@ func TestPostEditTeamValidationError(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("name=New+name&service=supervision&supervision-type=FINANCE&email=new@opgtest.com&phone=9876"))
+	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("name=New+name&service=supervision&supervision-type=FINANCE&email=new@opgtest.com&phone=9876&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-type", "application/x-www-form-urlencoded")
 
-	err := editTeam(client, template)(client.requiredPermissions(), w, r)
+	err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
 
+	expectedTeam := sirius.Team{
+		ID:          123,
+		DisplayName: "New name",
+		Type:        "FINANCE",
+		Email:       "new@opgtest.com",
+		PhoneNumber: "9876",
+	}
+
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(editTeamVars{
-		Path: "/teams/edit/123",
-		Team: sirius.Team{
-			ID:          123,
-			DisplayName: "New name",
-			Type:        "FINANCE",
-			Email:       "new@opgtest.com",
-			PhoneNumber: "9876",
-		},
+		Path:            "/teams/edit/123",
+		Team:            expectedTeam,
+		Hash:            teamHash(client.team.data),
 		TeamTypeOptions: client.teamTypes.data,
 		CanEditTeamType: true,
 		Errors:          validationErrors,
 	}, template.lastVars)
 }
 
+func TestPostEditTeamConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockEditTeamClient{}
+	client.team.data = sirius.Team{
+		ID:          123,
+		DisplayName: "Complaints team",
+		Type:        "COMPLAINTS",
+		Email:       "complaint@opgtest.com",
+		PhoneNumber: "01234",
+	}
+	template := &mockTemplate{}
+
+	seen := client.team.data
+	seen.PhoneNumber = "05678"
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("name=New+name&service=lpa&email=complaint@opgtest.com&phone=05678&hash="+teamHash(seen)))
+	r.Header.Add("Content-type", "application/x-www-form-urlencoded")
+
+	err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusConflict, w.Result().StatusCode)
+	assert.Equal(0, client.editTeam.count)
+
+	vars := template.lastVars.(editTeamVars)
+	assert.Equal(client.team.data, vars.Team)
+	assert.Equal(&teamConflict{
+		Hash: teamHash(client.team.data),
+		Fields: url.Values{
+			"name":    {"New name"},
+			"service": {"lpa"},
+			"email":   {"complaint@opgtest.com"},
+			"phone":   {"05678"},
+		},
+		Details: []teamDetailChange{
+			{Name: "Name", Before: "Complaints team", After: "New name"},
+			{Name: "Type", Before: "COMPLAINTS", After: ""},
+			{Name: "Phone number", Before: "01234", After: "05678"},
+		},
+	}, vars.Conflict)
+}
+
 func TestPostEditTeamOtherError(t *testing.T) {
 	assert := assert.New(t)
 
@@ -383,9 +443,10 @@ func TestPostEditTeamOtherError(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/edit/123", nil)
+	r, _ := http.NewRequest("POST", "/teams/edit/123", strings.NewReader("hash="+teamHash(client.team.data)))
+	r.Header.Add("Content-type", "application/x-www-form-urlencoded")
 
-	err := editTeam(client, template)(client.requiredPermissions(), w, r)
+	err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 
 	assert.Equal(expectedErr, err)
 
@@ -402,7 +463,7 @@ func TestPostEditTeamRetrieveError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/teams/edit/123", nil)
 
-	err := editTeam(client, template)(client.requiredPermissions(), w, r)
+	err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 
 	assert.Equal(StatusError(http.StatusNotFound), err)
 
@@ -420,7 +481,7 @@ func TestPostEditTeamRefDataError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/teams/edit/123", nil)
 
-	err := editTeam(client, template)(client.requiredPermissions(), w, r)
+	err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 
 	assert.Equal(StatusError(http.StatusNotFound), err)
 
@@ -439,7 +500,7 @@ func TestBadMethodEditTeam(t *testing.T) {
 
 	r, _ := http.NewRequest("DELETE", "/teams/edit/123", nil)
 
-	err := editTeam(client, template)(client.requiredPermissions(), nil, r)
+	err := editTeam(client, template, &mockAuditLogger{})(client.requiredPermissions(), nil, r)
 
 	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
 
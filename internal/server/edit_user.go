@@ -5,25 +5,43 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
 type EditUserClient interface {
 	User(sirius.Context, int) (sirius.AuthUser, error)
 	EditUser(sirius.Context, sirius.AuthUser) error
+	Organisations() []sirius.Organisation
 	Roles(sirius.Context) ([]string, error)
 }
 
 type editUserVars struct {
-	Path              string
-	XSRFToken         string
-	Roles             []string
-	User              sirius.AuthUser
-	Success           bool
-	Errors            sirius.ValidationErrors
+	Path            string
+	XSRFToken       string
+	Organisations   []sirius.Organisation
+	Roles           []string
+	User            sirius.AuthUser
+	Changes         []userChange
+	RolesAdded      []string
+	RolesRemoved    []string
+	PrivilegedRoles []string
+	NoChanges       bool
+	Success         bool
+	Errors          sirius.ValidationErrors
 }
 
-func editUser(client EditUserClient, tmpl Template) Handler {
+// privilegedRoles can only be added or removed once the admin has confirmed
+// that they meant to change them.
+var privilegedRoles = []string{"System Admin"}
+
+type userChange struct {
+	Name   string
+	Before string
+	After  string
+}
+
+func editUser(client EditUserClient, tmpl Template, audit AuditLogger, policy RolePolicy) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-users", http.MethodPut) {
 			return StatusError(http.StatusForbidden)
@@ -42,9 +60,10 @@ func editUser(client EditUserClient, tmpl Template) Handler {
 		}
 
 		vars := editUserVars{
-			Path:              r.URL.Path,
-			XSRFToken:         ctx.XSRFToken,
-			Roles:             roles,
+			Path:          r.URL.Path,
+			XSRFToken:     ctx.XSRFToken,
+			Organisations: client.Organisations(),
+			Roles:         roles,
 		}
 
 		switch r.Method {
@@ -58,16 +77,69 @@ func editUser(client EditUserClient, tmpl Template) Handler {
 			return tmpl.ExecuteTemplate(w, "page", vars)
 
 		case http.MethodPost:
-			vars.User = sirius.AuthUser{
-				ID:           id,
-				Firstname:    r.PostFormValue("firstname"),
-				Surname:      r.PostFormValue("surname"),
-				Organisation: r.PostFormValue("organisation"),
-				Roles:        r.PostForm["roles"],
-				Suspended:    r.PostFormValue("suspended") == "Yes",
-				Locked:       r.PostFormValue("locked") == "Yes",
+			// only the fields on the form are changed, so that the rest of the
+			// user is saved as it is in Sirius rather than as the browser sent it
+			user, err := client.User(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			vars.User = user
+			vars.User.Firstname = r.PostFormValue("firstname")
+			vars.User.Surname = r.PostFormValue("surname")
+			vars.User.Organisation = sirius.Organisation(r.PostFormValue("organisation"))
+			vars.User.Roles = r.PostForm["roles"]
+
+			if violations := policy.Check(vars.User.Organisation, vars.User.Roles); violations != nil {
+				vars.Errors = sirius.ValidationErrors{"roles": violations}
+
+				w.WriteHeader(http.StatusBadRequest)
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
+			vars.Changes = userChanges(user, vars.User)
+			vars.RolesAdded, vars.RolesRemoved = roleChanges(user.Roles, vars.User.Roles)
+			for _, role := range append(append([]string{}, vars.RolesAdded...), vars.RolesRemoved...) {
+				if containsString(privilegedRoles, role) {
+					vars.PrivilegedRoles = append(vars.PrivilegedRoles, role)
+				}
+			}
+
+			if len(vars.Changes) == 0 && len(vars.RolesAdded) == 0 && len(vars.RolesRemoved) == 0 {
+				vars.NoChanges = true
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
+			if r.PostFormValue("confirm") != "Yes" {
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
+			if len(vars.PrivilegedRoles) > 0 && r.PostFormValue("confirm-privileged") != "Yes" {
+				vars.Errors = sirius.ValidationErrors{
+					"confirm-privileged": {
+						"required": "Confirm that you want to change privileged roles",
+					},
+				}
+
+				w.WriteHeader(http.StatusBadRequest)
+				return tmpl.ExecuteTemplate(w, "page", vars)
 			}
-			err := client.EditUser(ctx, vars.User)
+
+			err = client.EditUser(ctx, vars.User)
+
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "edit-user",
+				TargetType: "user",
+				TargetID:   id,
+				Before:     user,
+				After:      vars.User,
+				Err:        err,
+			})
+
+			vars.Changes = nil
+			vars.RolesAdded = nil
+			vars.RolesRemoved = nil
+			vars.PrivilegedRoles = nil
 
 			if _, ok := err.(sirius.ClientError); ok {
 				vars.Errors = sirius.ValidationErrors{
@@ -85,7 +157,6 @@ func editUser(client EditUserClient, tmpl Template) Handler {
 			}
 
 			vars.Success = true
-			vars.User.Email = r.PostFormValue("email")
 			return tmpl.ExecuteTemplate(w, "page", vars)
 
 		default:
@@ -93,3 +164,37 @@ func editUser(client EditUserClient, tmpl Template) Handler {
 		}
 	}
 }
+
+// userChanges lists the fields of the edit user form, other than roles, that
+// differ between the two users.
+func userChanges(before, after sirius.AuthUser) []userChange {
+	var changes []userChange
+
+	for _, field := range []userChange{
+		{Name: "First name", Before: before.Firstname, After: after.Firstname},
+		{Name: "Last name", Before: before.Surname, After: after.Surname},
+		{Name: "Organisation", Before: before.Organisation.String(), After: after.Organisation.String()},
+	} {
+		if field.Before != field.After {
+			changes = append(changes, field)
+		}
+	}
+
+	return changes
+}
+
+func roleChanges(before, after []string) (added, removed []string) {
+	for _, role := range after {
+		if !containsString(before, role) {
+			added = append(added, role)
+		}
+	}
+
+	for _, role := range before {
+		if !containsString(after, role) {
+			removed = append(removed, role)
+		}
+	}
+
+	return added, removed
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 	"github.com/stretchr/testify/assert"
 )
@@ -57,6 +58,10 @@ func (m *mockEditUserClient) Roles(ctx sirius.Context) ([]string, error) {
 	return []string{"System Admin", "Manager"}, m.roles.err
 }
 
+func (m *mockEditUserClient) Organisations() []sirius.Organisation {
+	return []sirius.Organisation{"OPG User", "COP User"}
+}
+
 func (m *mockEditUserClient) requiredPermissions() sirius.PermissionSet {
 	return sirius.PermissionSet{"v1-users": sirius.PermissionGroup{Permissions: []string{"put"}}}
 }
@@ -71,7 +76,7 @@ func TestGetEditUser(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/edit-user/123", nil)
 
-	err := editUser(client, template)(client.requiredPermissions(), w, r)
+	err := editUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.roles.count)
@@ -83,9 +88,10 @@ func TestGetEditUser(t *testing.T) {
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(editUserVars{
-		Path:  "/edit-user/123",
-		User:  client.user.data,
-		Roles: []string{"System Admin", "Manager"},
+		Path:          "/edit-user/123",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		User:          client.user.data,
+		Roles:         []string{"System Admin", "Manager"},
 	}, template.lastVars)
 }
 
@@ -95,7 +101,7 @@ func TestGetEditUserNoPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := editUser(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := editUser(nil, nil, &mockAuditLogger{}, &mockRolePolicy{})(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -112,7 +118,7 @@ func TestGetEditUserBadPath(t *testing.T) {
 			w := httptest.NewRecorder()
 			r, _ := http.NewRequest("GET", path, nil)
 
-			err := editUser(nil, nil)(client.requiredPermissions(), w, r)
+			err := editUser(nil, nil, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 			assert.Equal(StatusError(http.StatusNotFound), err)
 		})
 	}
@@ -122,48 +128,252 @@ func TestPostEditUser(t *testing.T) {
 	assert := assert.New(t)
 
 	client := &mockEditUserClient{}
-	client.user.data = sirius.AuthUser{Firstname: "test"}
+	client.user.data = sirius.AuthUser{
+		ID:           123,
+		Email:        "a",
+		Firstname:    "b",
+		Surname:      "x",
+		Organisation: "d",
+		Roles:        []string{"f", "e"},
+		Locked:       true,
+		Inactive:     true,
+	}
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader("email=a&firstname=b&surname=c&organisation=d&roles=e&roles=f&locked=Yes&suspended=No"))
+	r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader("firstname=b&surname=c&organisation=d&roles=e&roles=g"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	audit := &mockAuditLogger{}
 
-	err := editUser(client, template)(client.requiredPermissions(), w, r)
+	err := editUser(client, template, audit, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.roles.count)
-	assert.Equal(getContext(r), client.roles.lastCtx)
+	assert.Equal(1, client.user.count)
+	assert.Equal(getContext(r), client.user.lastCtx)
+	assert.Equal(123, client.user.lastID)
+	assert.Equal(0, client.editUser.count)
+	assert.Equal(0, audit.count)
 
-	assert.Equal(1, client.editUser.count)
-	assert.Equal(getContext(r), client.editUser.lastCtx)
-	assert.Equal(sirius.AuthUser{
+	assert.Equal(1, template.count)
+	assert.Equal("page", template.lastName)
+	assert.Equal(editUserVars{
+		Path:          "/edit-user/123",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Roles:         []string{"System Admin", "Manager"},
+		User: sirius.AuthUser{
+			ID:           123,
+			Email:        "a",
+			Firstname:    "b",
+			Surname:      "c",
+			Organisation: "d",
+			Roles:        []string{"e", "g"},
+			Locked:       true,
+			Inactive:     true,
+		},
+		Changes: []userChange{
+			{Name: "Last name", Before: "x", After: "c"},
+		},
+		RolesAdded:   []string{"g"},
+		RolesRemoved: []string{"f"},
+	}, template.lastVars)
+}
+
+func TestPostEditUserRolePolicyViolation(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockEditUserClient{}
+	client.user.data = sirius.AuthUser{ID: 123, Email: "a", Organisation: "d", Roles: []string{"e"}}
+	template := &mockTemplate{}
+	policy := &mockRolePolicy{}
+	policy.data = map[string]string{"exclusive": "A user cannot have both e and f"}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader("firstname=b&surname=c&organisation=d&roles=e&roles=f&confirm=Yes"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := editUser(client, template, &mockAuditLogger{}, policy)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(1, policy.count)
+	assert.Equal(sirius.Organisation("d"), policy.lastOrganisation)
+	assert.Equal([]string{"e", "f"}, policy.lastRoles)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(0, client.editUser.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal(editUserVars{
+		Path:          "/edit-user/123",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Roles:         []string{"System Admin", "Manager"},
+		User: sirius.AuthUser{
+			ID:           123,
+			Email:        "a",
+			Firstname:    "b",
+			Surname:      "c",
+			Organisation: "d",
+			Roles:        []string{"e", "f"},
+		},
+		Errors: sirius.ValidationErrors{
+			"roles": {"exclusive": "A user cannot have both e and f"},
+		},
+	}, template.lastVars)
+}
+
+func TestPostEditUserPrivilegedRoles(t *testing.T) {
+	for name, tc := range map[string]struct {
+		before []string
+		form   string
+		added  []string
+		remove []string
+	}{
+		"added": {
+			before: []string{"Manager"},
+			form:   "roles=Manager&roles=System+Admin",
+			added:  []string{"System Admin"},
+		},
+		"removed": {
+			before: []string{"Manager", "System Admin"},
+			form:   "roles=Manager",
+			remove: []string{"System Admin"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockEditUserClient{}
+			client.user.data = sirius.AuthUser{ID: 123, Roles: tc.before}
+			template := &mockTemplate{}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader(tc.form+"&confirm=Yes"))
+			r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+			err := editUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+			assert.Nil(err)
+
+			assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+			assert.Equal(0, client.editUser.count)
+
+			vars := template.lastVars.(editUserVars)
+			assert.Equal(tc.added, vars.RolesAdded)
+			assert.Equal(tc.remove, vars.RolesRemoved)
+			assert.Equal([]string{"System Admin"}, vars.PrivilegedRoles)
+			assert.Equal(sirius.ValidationErrors{
+				"confirm-privileged": {
+					"required": "Confirm that you want to change privileged roles",
+				},
+			}, vars.Errors)
+
+			w = httptest.NewRecorder()
+			r, _ = http.NewRequest("POST", "/edit-user/123", strings.NewReader(tc.form+"&confirm=Yes&confirm-privileged=Yes"))
+			r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+			err = editUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+			assert.Nil(err)
+
+			assert.Equal(http.StatusOK, w.Result().StatusCode)
+			assert.Equal(1, client.editUser.count)
+			assert.True(template.lastVars.(editUserVars).Success)
+		})
+	}
+}
+
+func TestPostEditUserConfirm(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockEditUserClient{}
+	client.user.data = sirius.AuthUser{
+		ID:           123,
+		Email:        "a",
+		Firstname:    "b",
+		Surname:      "x",
+		Organisation: "d",
+		Roles:        []string{"e"},
+		Locked:       true,
+		Suspended:    true,
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader("firstname=b&surname=c&organisation=d&roles=e&roles=f&locked=No&suspended=No&confirm=Yes"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	audit := &mockAuditLogger{}
+
+	err := editUser(client, template, audit, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	expectedUser := sirius.AuthUser{
 		ID:           123,
+		Email:        "a",
 		Firstname:    "b",
 		Surname:      "c",
 		Organisation: "d",
 		Roles:        []string{"e", "f"},
 		Locked:       true,
-		Suspended:    false,
-	}, client.editUser.lastUser)
+		Suspended:    true,
+	}
 
-	assert.Equal(0, client.user.count)
+	assert.Equal(1, client.user.count)
+	assert.Equal(1, client.editUser.count)
+	assert.Equal(getContext(r), client.editUser.lastCtx)
+	assert.Equal(expectedUser, client.editUser.lastUser)
+
+	assert.Equal(1, audit.count)
+	assert.Equal(logging.AuditEvent{
+		Action:     "edit-user",
+		TargetType: "user",
+		TargetID:   123,
+		Before:     client.user.data,
+		After:      expectedUser,
+	}, audit.lastEvent())
 
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(editUserVars{
-		Path:    "/edit-user/123",
-		Success: true,
-		Roles:   []string{"System Admin", "Manager"},
+		Path:          "/edit-user/123",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Success:       true,
+		Roles:         []string{"System Admin", "Manager"},
+		User:          expectedUser,
+	}, template.lastVars)
+}
+
+func TestPostEditUserNoChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockEditUserClient{}
+	client.user.data = sirius.AuthUser{
+		ID:           123,
+		Firstname:    "b",
+		Surname:      "c",
+		Organisation: "d",
+		Roles:        []string{"f", "e"},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader("firstname=b&surname=c&organisation=d&roles=e&roles=f&confirm=Yes"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := editUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(0, client.editUser.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal(editUserVars{
+		Path:          "/edit-user/123",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Roles:         []string{"System Admin", "Manager"},
+		NoChanges:     true,
 		User: sirius.AuthUser{
 			ID:           123,
-			Email:        "a",
 			Firstname:    "b",
 			Surname:      "c",
 			Organisation: "d",
 			Roles:        []string{"e", "f"},
-			Locked:       true,
-			Suspended:    false,
 		},
 	}, template.lastVars)
 }
@@ -172,33 +382,35 @@ func TestPostEditUserClientError(t *testing.T) {
 	assert := assert.New(t)
 
 	client := &mockEditUserClient{}
+	client.user.data = sirius.AuthUser{ID: 123, Email: "a", Firstname: "x"}
 	client.editUser.err = sirius.ClientError("something")
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader("email=a&firstname=b&surname=c&organisation=d&roles=e&roles=f&locked=Yes&suspended=No"))
+	r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader("firstname=b&surname=c&organisation=d&roles=e&roles=f&confirm=Yes"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := editUser(client, template)(client.requiredPermissions(), w, r)
+	err := editUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.roles.count)
 	assert.Equal(1, client.editUser.count)
-	assert.Equal(0, client.user.count)
+	assert.Equal(1, client.user.count)
 
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(editUserVars{
-		Path:  "/edit-user/123",
-		Roles: []string{"System Admin", "Manager"},
+		Path:          "/edit-user/123",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Roles:         []string{"System Admin", "Manager"},
 		User: sirius.AuthUser{
 			ID:           123,
+			Email:        "a",
 			Firstname:    "b",
 			Surname:      "c",
 			Organisation: "d",
 			Roles:        []string{"e", "f"},
-			Locked:       true,
-			Suspended:    false,
 		},
 		Errors: sirius.ValidationErrors{
 			"firstname": {
@@ -217,14 +429,37 @@ func TestPostEditUserOtherError(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/edit-user/123", nil)
+	r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader("firstname=b&confirm=Yes"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	audit := &mockAuditLogger{}
 
-	err := editUser(client, template)(client.requiredPermissions(), w, r)
+	err := editUser(client, template, audit, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedErr, err)
+	assert.Equal(expectedErr, audit.lastEvent().Err)
 
 	assert.Equal(1, client.roles.count)
 	assert.Equal(1, client.editUser.count)
-	assert.Equal(0, client.user.count)
+	assert.Equal(1, client.user.count)
+	assert.Equal(0, template.count)
+}
+
+func TestPostEditUserUserError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("oops")
+	client := &mockEditUserClient{}
+	client.user.err = expectedErr
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/edit-user/123", strings.NewReader("firstname=b&confirm=Yes"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := editUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Equal(expectedErr, err)
+
+	assert.Equal(1, client.user.count)
+	assert.Equal(0, client.editUser.count)
 	assert.Equal(0, template.count)
 }
 
@@ -239,7 +474,7 @@ func TestPostEditUserRolesError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/edit-user/123", nil)
 
-	err := editUser(client, template)(client.requiredPermissions(), w, r)
+	err := editUser(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedErr, err)
 
 	assert.Equal(1, client.roles.count)
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+type ExportUsersClient interface {
+	Teams(sirius.Context) ([]sirius.Team, error)
+	Team(sirius.Context, int) (sirius.Team, error)
+	User(sirius.Context, int) (sirius.AuthUser, error)
+}
+
+// csvFormulaPrefixes are the characters that make a spreadsheet treat a cell
+// as a formula.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+type exportUsersRow struct {
+	Team         string   `json:"team"`
+	Member       string   `json:"member"`
+	Email        string   `json:"email"`
+	Organisation string   `json:"organisation"`
+	Roles        []string `json:"roles"`
+	Locked       bool     `json:"locked"`
+	Suspended    bool     `json:"suspended"`
+}
+
+func exportUsers(client ExportUsersClient) Handler {
+	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		if !perm.HasPermission("v1-users", http.MethodPut) {
+			return StatusError(http.StatusForbidden)
+		}
+
+		if r.Method != http.MethodGet {
+			return StatusError(http.StatusMethodNotAllowed)
+		}
+
+		format := r.FormValue("format")
+		if format == "" {
+			format = "csv"
+		}
+
+		if format != "csv" && format != "json" {
+			return StatusError(http.StatusNotFound)
+		}
+
+		ctx := getContext(r)
+
+		teams, err := client.Teams(ctx)
+		if err != nil {
+			return err
+		}
+
+		// every team is fetched before anything is written, so that an error
+		// from Sirius can still be shown as an error page rather than leaving a
+		// partial file; the response is therefore held in memory until then
+		users := map[int]sirius.AuthUser{}
+		rows := []exportUsersRow{}
+
+		for _, t := range teams {
+			team, err := client.Team(ctx, t.ID)
+			if err != nil {
+				return err
+			}
+
+			for _, member := range team.Members {
+				user, ok := users[member.ID]
+				if !ok {
+					user, err = client.User(ctx, member.ID)
+					if err != nil {
+						return err
+					}
+
+					users[member.ID] = user
+				}
+
+				rows = append(rows, exportUsersRow{
+					Team:         team.DisplayName,
+					Member:       member.DisplayName,
+					Email:        member.Email,
+					Organisation: user.Organisation.String(),
+					Roles:        user.Roles,
+					Locked:       user.Locked,
+					Suspended:    user.Suspended,
+				})
+			}
+		}
+
+		w.Header().Set("Content-Disposition", "attachment; filename=\"users."+format+"\"")
+
+		if format == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			return json.NewEncoder(w).Encode(rows)
+		}
+
+		w.Header().Set("Content-Type", "text/csv")
+
+		out := csv.NewWriter(w)
+		_ = out.Write([]string{"team", "member", "email", "organisation", "roles", "locked", "suspended"})
+
+		for _, row := range rows {
+			_ = out.Write([]string{
+				escapeCSVCell(row.Team),
+				escapeCSVCell(row.Member),
+				escapeCSVCell(row.Email),
+				escapeCSVCell(row.Organisation),
+				escapeCSVCell(strings.Join(row.Roles, ";")),
+				strconv.FormatBool(row.Locked),
+				strconv.FormatBool(row.Suspended),
+			})
+		}
+
+		out.Flush()
+		return out.Error()
+	}
+}
+
+// escapeCSVCell stops a value being run as a formula when the file is opened
+// in a spreadsheet, by starting it with a quote.
+func escapeCSVCell(s string) string {
+	if s != "" && strings.ContainsRune(csvFormulaPrefixes, rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
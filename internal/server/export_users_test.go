@@ -0,0 +1,247 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockExportUsersClient struct {
+	teams struct {
+		count   int
+		lastCtx sirius.Context
+		data    []sirius.Team
+		err     error
+	}
+	team struct {
+		count   int
+		lastCtx sirius.Context
+		lastID  []int
+		data    map[int]sirius.Team
+		err     error
+	}
+	user struct {
+		count   int
+		lastCtx sirius.Context
+		lastID  []int
+		data    map[int]sirius.AuthUser
+		err     error
+	}
+}
+
+func (m *mockExportUsersClient) Teams(ctx sirius.Context) ([]sirius.Team, error) {
+	m.teams.count += 1
+	m.teams.lastCtx = ctx
+
+	return m.teams.data, m.teams.err
+}
+
+func (m *mockExportUsersClient) Team(ctx sirius.Context, id int) (sirius.Team, error) {
+	m.team.count += 1
+	m.team.lastCtx = ctx
+	m.team.lastID = append(m.team.lastID, id)
+
+	return m.team.data[id], m.team.err
+}
+
+func (m *mockExportUsersClient) User(ctx sirius.Context, id int) (sirius.AuthUser, error) {
+	m.user.count += 1
+	m.user.lastCtx = ctx
+	m.user.lastID = append(m.user.lastID, id)
+
+	return m.user.data[id], m.user.err
+}
+
+func (m *mockExportUsersClient) requiredPermissions() sirius.PermissionSet {
+	return sirius.PermissionSet{"v1-users": sirius.PermissionGroup{Permissions: []string{"put"}}}
+}
+
+func newMockExportUsersClient() *mockExportUsersClient {
+	client := &mockExportUsersClient{}
+	client.teams.data = []sirius.Team{{ID: 1}, {ID: 2}}
+	client.team.data = map[int]sirius.Team{
+		1: {
+			ID:          1,
+			DisplayName: "Casework, A",
+			Members: []sirius.TeamMember{
+				{ID: 10, DisplayName: "Anne Admin", Email: "anne@opgtest.com"},
+				{ID: 11, DisplayName: "Bob Builder", Email: "bob@opgtest.com"},
+			},
+		},
+		2: {
+			ID:          2,
+			DisplayName: "Allocations",
+			Members: []sirius.TeamMember{
+				{ID: 10, DisplayName: "Anne Admin", Email: "anne@opgtest.com"},
+			},
+		},
+	}
+	client.user.data = map[int]sirius.AuthUser{
+		10: {ID: 10, Organisation: "OPG User", Roles: []string{"System Admin", "Manager"}},
+		11: {ID: 11, Organisation: "COP User", Roles: []string{"Case Manager"}, Locked: true, Suspended: true},
+	}
+
+	return client
+}
+
+func TestExportUsersCSV(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockExportUsersClient()
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/export", nil)
+
+	err := exportUsers(client)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(1, client.teams.count)
+	assert.Equal(getContext(r), client.teams.lastCtx)
+	assert.Equal([]int{1, 2}, client.team.lastID)
+	assert.Equal([]int{10, 11}, client.user.lastID)
+
+	resp := w.Result()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("text/csv", resp.Header.Get("Content-Type"))
+	assert.Equal(`attachment; filename="users.csv"`, resp.Header.Get("Content-Disposition"))
+	assert.Equal(`team,member,email,organisation,roles,locked,suspended
+"Casework, A",Anne Admin,anne@opgtest.com,OPG User,System Admin;Manager,false,false
+"Casework, A",Bob Builder,bob@opgtest.com,COP User,Case Manager,true,true
+Allocations,Anne Admin,anne@opgtest.com,OPG User,System Admin;Manager,false,false
+`, w.Body.String())
+}
+
+func TestExportUsersCSVEscapesFormulas(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockExportUsersClient{}
+	client.teams.data = []sirius.Team{{ID: 1}}
+	client.team.data = map[int]sirius.Team{
+		1: {
+			ID:          1,
+			DisplayName: "=HYPERLINK(\"http://example.com\")",
+			Members: []sirius.TeamMember{
+				{ID: 10, DisplayName: "+Anne", Email: "@anne@opgtest.com"},
+				{ID: 11, DisplayName: "-Bob", Email: "\tbob@opgtest.com"},
+			},
+		},
+	}
+	client.user.data = map[int]sirius.AuthUser{
+		10: {ID: 10},
+		11: {ID: 11},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/export", nil)
+
+	err := exportUsers(client)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(`team,member,email,organisation,roles,locked,suspended
+"'=HYPERLINK(""http://example.com"")",'+Anne,'@anne@opgtest.com,,,false,false
+"'=HYPERLINK(""http://example.com"")",'-Bob,'	bob@opgtest.com,,,false,false
+`, w.Body.String())
+}
+
+func TestExportUsersJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockExportUsersClient()
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/export?format=json", nil)
+
+	err := exportUsers(client)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	resp := w.Result()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(`attachment; filename="users.json"`, resp.Header.Get("Content-Disposition"))
+	assert.JSONEq(`[
+  {"team":"Casework, A","member":"Anne Admin","email":"anne@opgtest.com","organisation":"OPG User","roles":["System Admin","Manager"],"locked":false,"suspended":false},
+  {"team":"Casework, A","member":"Bob Builder","email":"bob@opgtest.com","organisation":"COP User","roles":["Case Manager"],"locked":true,"suspended":true},
+  {"team":"Allocations","member":"Anne Admin","email":"anne@opgtest.com","organisation":"OPG User","roles":["System Admin","Manager"],"locked":false,"suspended":false}
+]`, w.Body.String())
+}
+
+func TestExportUsersNoTeams(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockExportUsersClient{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/export?format=json", nil)
+
+	err := exportUsers(client)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal("[]\n", w.Body.String())
+}
+
+func TestExportUsersNoPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/export", nil)
+
+	err := exportUsers(nil)(sirius.PermissionSet{
+		"v1-users": sirius.PermissionGroup{Permissions: []string{"get"}},
+	}, w, r)
+	assert.Equal(StatusError(http.StatusForbidden), err)
+}
+
+func TestExportUsersUnknownFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockExportUsersClient{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/export?format=xml", nil)
+
+	err := exportUsers(client)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusNotFound), err)
+
+	assert.Equal(0, client.teams.count)
+}
+
+func TestExportUsersErrors(t *testing.T) {
+	expectedError := errors.New("oops")
+
+	for name, setup := range map[string]func(*mockExportUsersClient){
+		"teams": func(c *mockExportUsersClient) { c.teams.err = expectedError },
+		"team":  func(c *mockExportUsersClient) { c.team.err = expectedError },
+		"user":  func(c *mockExportUsersClient) { c.user.err = expectedError },
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := newMockExportUsersClient()
+			setup(client)
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/users/export", nil)
+
+			err := exportUsers(client)(client.requiredPermissions(), w, r)
+			assert.Equal(expectedError, err)
+
+			assert.Equal("", w.Body.String())
+		})
+	}
+}
+
+func TestPostExportUsers(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockExportUsersClient{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/users/export", nil)
+
+	err := exportUsers(client)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
+}
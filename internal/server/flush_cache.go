@@ -0,0 +1,56 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+type FlushCacheClient interface {
+	FlushCache()
+}
+
+func flushCache(client FlushCacheClient, audit AuditLogger) Handler {
+	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		if !perm.HasPermission("v1-users", http.MethodPut) {
+			return StatusError(http.StatusForbidden)
+		}
+
+		if r.Method != http.MethodPost {
+			return StatusError(http.StatusMethodNotAllowed)
+		}
+
+		// nothing is sent to Sirius, so the token must be checked here
+		if !validXSRFToken(r) {
+			return StatusError(http.StatusForbidden)
+		}
+
+		client.FlushCache()
+
+		audit.Audit(r, logging.AuditEvent{
+			Action: "flush-cache",
+		})
+
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+}
+
+// validXSRFToken checks that the token posted with r matches the one set in
+// its XSRF-TOKEN cookie.
+func validXSRFToken(r *http.Request) bool {
+	cookie, err := r.Cookie("XSRF-TOKEN")
+	if err != nil {
+		return false
+	}
+
+	expected, err := url.QueryUnescape(cookie.Value)
+	if err != nil || expected == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(r.PostFormValue("xsrfToken"))) == 1
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockFlushCacheClient struct {
+	count int
+}
+
+func (m *mockFlushCacheClient) FlushCache() {
+	m.count += 1
+}
+
+func (m *mockFlushCacheClient) requiredPermissions() sirius.PermissionSet {
+	return sirius.PermissionSet{"v1-users": sirius.PermissionGroup{Permissions: []string{"put"}}}
+}
+
+func TestPostFlushCache(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockFlushCacheClient{}
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/cache/flush", strings.NewReader("xsrfToken=abc%2Fde"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.AddCookie(&http.Cookie{Name: "XSRF-TOKEN", Value: "abc%2Fde"})
+
+	err := flushCache(client, audit)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusNoContent, w.Result().StatusCode)
+	assert.Equal(1, client.count)
+	assert.Equal(logging.AuditEvent{Action: "flush-cache"}, audit.lastEvent())
+}
+
+func TestPostFlushCacheBadXSRFToken(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cookie string
+		body   string
+	}{
+		"missing cookie": {body: "xsrfToken=abcde"},
+		"missing token":  {cookie: "abcde"},
+		"empty":          {cookie: "", body: "xsrfToken="},
+		"mismatch":       {cookie: "abcde", body: "xsrfToken=fghij"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockFlushCacheClient{}
+			audit := &mockAuditLogger{}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/cache/flush", strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "XSRF-TOKEN", Value: tc.cookie})
+			}
+
+			err := flushCache(client, audit)(client.requiredPermissions(), w, r)
+			assert.Equal(StatusError(http.StatusForbidden), err)
+			assert.Equal(0, client.count)
+			assert.Equal(0, audit.count)
+		})
+	}
+}
+
+func TestFlushCacheNoPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockFlushCacheClient{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/cache/flush", nil)
+
+	err := flushCache(client, nil)(sirius.PermissionSet{}, w, r)
+	assert.Equal(StatusError(http.StatusForbidden), err)
+	assert.Equal(0, client.count)
+}
+
+func TestGetFlushCache(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockFlushCacheClient{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/cache/flush", nil)
+
+	err := flushCache(client, nil)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
+	assert.Equal(0, client.count)
+}
@@ -1,9 +1,87 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
+const readinessTimeout = 5 * time.Second
+
+type HealthCheckClient interface {
+	Ping(sirius.Context) error
+}
+
+type healthCheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthCheckVars struct {
+	Status string                       `json:"status"`
+	Checks map[string]healthCheckResult `json:"checks,omitempty"`
+}
+
 func healthCheck() http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealthCheck(w, healthCheckVars{Status: "ok"})
+	})
+}
+
+// readinessCheck does not check the templates, as New will not start without
+// them and they cannot change while running.
+func readinessCheck(client HealthCheckClient, webDir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+		defer cancel()
+
+		checks := map[string]error{
+			"static": checkStatic(webDir),
+			"sirius": client.Ping(sirius.Context{Context: ctx}),
+		}
+
+		vars := healthCheckVars{
+			Status: "ok",
+			Checks: map[string]healthCheckResult{},
+		}
+
+		for name, err := range checks {
+			if err != nil {
+				vars.Status = "fail"
+				vars.Checks[name] = healthCheckResult{Status: "fail", Error: err.Error()}
+			} else {
+				vars.Checks[name] = healthCheckResult{Status: "ok"}
+			}
+		}
+
+		writeHealthCheck(w, vars)
+	})
+}
+
+func writeHealthCheck(w http.ResponseWriter, vars healthCheckVars) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if vars.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+
+	_ = json.NewEncoder(w).Encode(vars)
+}
+
+func checkStatic(webDir string) error {
+	info, err := os.Stat(webDir + "/static")
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%s/static is not a directory", webDir)
+	}
+
+	return nil
 }
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockHealthCheckClient struct {
+	count   int
+	lastCtx sirius.Context
+	err     error
+}
+
+func (m *mockHealthCheckClient) Ping(ctx sirius.Context) error {
+	m.count += 1
+	m.lastCtx = ctx
+
+	return m.err
+}
+
+func TestHealthCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/health-check/live", nil)
+
+	healthCheck().ServeHTTP(w, r)
+
+	resp := w.Result()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(`{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadinessCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	webDir, _ := ioutil.TempDir("", "web")
+	defer os.RemoveAll(webDir)
+	_ = os.Mkdir(webDir+"/static", 0755)
+
+	client := &mockHealthCheckClient{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/health-check/ready", nil)
+
+	readinessCheck(client, webDir).ServeHTTP(w, r)
+
+	assert.Equal(1, client.count)
+
+	resp := w.Result()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(`{
+  "status": "ok",
+  "checks": {
+    "static": {"status": "ok"},
+    "sirius": {"status": "ok"}
+  }
+}`, w.Body.String())
+}
+
+func TestReadinessCheckFailures(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockHealthCheckClient{err: errors.New("oops")}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/health-check/ready", nil)
+
+	readinessCheck(client, "/does-not-exist").ServeHTTP(w, r)
+
+	resp := w.Result()
+	assert.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+	assert.JSONEq(`{
+  "status": "fail",
+  "checks": {
+    "static": {"status": "fail", "error": "stat /does-not-exist/static: no such file or directory"},
+    "sirius": {"status": "fail", "error": "oops"}
+  }
+}`, w.Body.String())
+}
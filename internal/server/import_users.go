@@ -0,0 +1,306 @@
+package server
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+const maxImportSize = 1 << 20
+
+var importColumns = []string{"email", "firstname", "surname", "organisation", "roles"}
+
+type ImportUsersClient interface {
+	AddUser(ctx sirius.Context, email, firstname, surname string, organisation sirius.Organisation, roles []string) error
+	Organisations() []sirius.Organisation
+	Roles(sirius.Context) ([]string, error)
+}
+
+type importUser struct {
+	Row          int
+	Email        string
+	Firstname    string
+	Surname      string
+	Organisation sirius.Organisation
+	Roles        []string
+	Added        bool
+	Errors       sirius.ValidationErrors
+}
+
+type importUsersVars struct {
+	Path          string
+	XSRFToken     string
+	Organisations []sirius.Organisation
+	CSV           string
+	Users         []importUser
+	Valid         int
+	Added         int
+	Confirmed     bool
+	Errors        sirius.ValidationErrors
+}
+
+func importUsers(client ImportUsersClient, tmpl Template, audit AuditLogger, policy RolePolicy) Handler {
+	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		if !perm.HasPermission("v1-users", http.MethodPost) {
+			return StatusError(http.StatusForbidden)
+		}
+
+		ctx := getContext(r)
+
+		vars := importUsersVars{
+			Path:          r.URL.Path,
+			XSRFToken:     ctx.XSRFToken,
+			Organisations: client.Organisations(),
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			return tmpl.ExecuteTemplate(w, "page", vars)
+
+		case http.MethodPost:
+			if r.PostFormValue("confirm") != "" {
+				vars.CSV = r.PostFormValue("csv")
+			} else {
+				data, err := readImportFile(r)
+				if err != nil {
+					vars.Errors = sirius.ValidationErrors{
+						"file": {
+							"": err.Error(),
+						},
+					}
+
+					w.WriteHeader(http.StatusBadRequest)
+					return tmpl.ExecuteTemplate(w, "page", vars)
+				}
+
+				vars.CSV = data
+			}
+
+			users, err := parseImportUsers(vars.CSV)
+			if err != nil {
+				vars.CSV = ""
+				vars.Errors = sirius.ValidationErrors{
+					"file": {
+						"": err.Error(),
+					},
+				}
+
+				w.WriteHeader(http.StatusBadRequest)
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
+			roles, err := client.Roles(ctx)
+			if err != nil {
+				return err
+			}
+
+			validateImportUsers(users, vars.Organisations, roles, policy)
+
+			for _, user := range users {
+				if user.Errors == nil {
+					vars.Valid++
+				}
+			}
+
+			if r.PostFormValue("confirm") != "" {
+				for i, user := range users {
+					if user.Errors != nil {
+						continue
+					}
+
+					err := client.AddUser(ctx, user.Email, user.Firstname, user.Surname, user.Organisation, user.Roles)
+
+					audit.Audit(r, logging.AuditEvent{
+						Action:     "add-user",
+						TargetType: "user",
+						After: sirius.AuthUser{
+							Email:        user.Email,
+							Firstname:    user.Firstname,
+							Surname:      user.Surname,
+							Organisation: user.Organisation,
+							Roles:        user.Roles,
+						},
+						Err: err,
+					})
+
+					if err == sirius.ErrUnauthorized {
+						return err
+					}
+
+					if verr, ok := err.(sirius.ValidationError); ok {
+						users[i].Errors = verr.Errors
+					} else if err != nil {
+						users[i].Errors = sirius.ValidationErrors{
+							"": {
+								"": err.Error(),
+							},
+						}
+					} else {
+						users[i].Added = true
+						vars.Added++
+					}
+				}
+
+				vars.Confirmed = true
+			}
+
+			vars.Users = users
+			return tmpl.ExecuteTemplate(w, "page", vars)
+
+		default:
+			return StatusError(http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+func readImportFile(r *http.Request) (string, error) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return "", errors.New("Select a CSV file to upload")
+	}
+	defer file.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(file, maxImportSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) > maxImportSize {
+		return "", errors.New("The selected file must be smaller than 1MB")
+	}
+
+	return string(data), nil
+}
+
+func parseImportUsers(data string) ([]importUser, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("The selected file is empty")
+	} else if err != nil {
+		return nil, errors.New("The selected file must be a CSV")
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, errors.New("The selected file must have the columns " + strings.Join(importColumns, ", "))
+		}
+	}
+
+	var users []importUser
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.New("The selected file must be a CSV")
+		}
+
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+
+			return ""
+		}
+
+		user := importUser{
+			Row:          row,
+			Email:        field("email"),
+			Firstname:    field("firstname"),
+			Surname:      field("surname"),
+			Organisation: sirius.Organisation(field("organisation")),
+		}
+
+		for _, role := range strings.Split(field("roles"), ";") {
+			if role = strings.TrimSpace(role); role != "" {
+				user.Roles = append(user.Roles, role)
+			}
+		}
+
+		if user.Email == "" && user.Firstname == "" && user.Surname == "" && user.Organisation == "" && len(user.Roles) == 0 {
+			continue
+		}
+
+		users = append(users, user)
+	}
+
+	if len(users) == 0 {
+		return nil, errors.New("The selected file does not contain any users")
+	}
+
+	return users, nil
+}
+
+func validateImportUsers(users []importUser, organisations []sirius.Organisation, roles []string, policy RolePolicy) {
+	knownRoles := map[string]bool{}
+	for _, role := range roles {
+		knownRoles[role] = true
+	}
+
+	seen := map[string]bool{}
+
+	for i, user := range users {
+		errs := sirius.ValidationErrors{}
+
+		if user.Email == "" {
+			errs["email"] = map[string]string{"isEmpty": "Enter an email address"}
+		} else if !strings.Contains(user.Email, "@") {
+			errs["email"] = map[string]string{"emailAddressInvalidFormat": "Enter a valid email address"}
+		} else if seen[strings.ToLower(user.Email)] {
+			errs["email"] = map[string]string{"emailAddressDuplicated": "Email address appears more than once in the file"}
+		}
+		seen[strings.ToLower(user.Email)] = true
+
+		if user.Firstname == "" {
+			errs["firstname"] = map[string]string{"isEmpty": "Enter a first name"}
+		}
+
+		if user.Surname == "" {
+			errs["surname"] = map[string]string{"isEmpty": "Enter a last name"}
+		}
+
+		if !containsOrganisation(organisations, user.Organisation) {
+			errs["organisation"] = map[string]string{"notInArray": "Organisation must be " + joinOrganisations(organisations)}
+		}
+
+		var unknownRoles []string
+		for _, role := range user.Roles {
+			if !knownRoles[role] {
+				unknownRoles = append(unknownRoles, role)
+			}
+		}
+
+		if len(unknownRoles) > 0 {
+			errs["roles"] = map[string]string{"notInArray": "Unknown roles: " + strings.Join(unknownRoles, ", ")}
+		}
+
+		if violations := policy.Check(user.Organisation, user.Roles); violations != nil {
+			if errs["roles"] == nil {
+				errs["roles"] = map[string]string{}
+			}
+
+			for rule, message := range violations {
+				errs["roles"][rule] = message
+			}
+		}
+
+		if len(errs) > 0 {
+			users[i].Errors = errs
+		}
+	}
+}
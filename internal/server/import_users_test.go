@@ -0,0 +1,391 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockImportUsersClient struct {
+	addUser struct {
+		count     int
+		lastCtx   sirius.Context
+		lastEmail []string
+		lastRoles [][]string
+		err       map[string]error
+	}
+
+	roles struct {
+		count   int
+		lastCtx sirius.Context
+		err     error
+	}
+}
+
+func (m *mockImportUsersClient) AddUser(ctx sirius.Context, email, firstname, surname string, organisation sirius.Organisation, roles []string) error {
+	m.addUser.count += 1
+	m.addUser.lastCtx = ctx
+	m.addUser.lastEmail = append(m.addUser.lastEmail, email)
+	m.addUser.lastRoles = append(m.addUser.lastRoles, roles)
+
+	return m.addUser.err[email]
+}
+
+func (m *mockImportUsersClient) Roles(ctx sirius.Context) ([]string, error) {
+	m.roles.count += 1
+	m.roles.lastCtx = ctx
+
+	return []string{"System Admin", "Manager"}, m.roles.err
+}
+
+func (m *mockImportUsersClient) Organisations() []sirius.Organisation {
+	return []sirius.Organisation{"OPG User", "COP User"}
+}
+
+func (m *mockImportUsersClient) requiredPermissions() sirius.PermissionSet {
+	return sirius.PermissionSet{"v1-users": sirius.PermissionGroup{Permissions: []string{"post"}}}
+}
+
+const importUsersCSV = `email,firstname,surname,organisation,roles
+one@opgtest.com,One,Person,OPG User,System Admin;Manager
+two@opgtest.com,,Person,Somewhere,Other
+`
+
+func newImportUsersUpload(content string) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	_ = form.WriteField("xsrfToken", "abcde")
+	part, _ := form.CreateFormFile("file", "users.csv")
+	_, _ = part.Write([]byte(content))
+	_ = form.Close()
+
+	r, _ := http.NewRequest("POST", "/users/import", &body)
+	r.Header.Add("Content-Type", form.FormDataContentType())
+	return r
+}
+
+func TestGetImportUsers(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockImportUsersClient{}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/import", nil)
+
+	err := importUsers(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(0, client.roles.count)
+	assert.Equal(0, client.addUser.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal("page", template.lastName)
+	assert.Equal(importUsersVars{
+		Path:          "/users/import",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+	}, template.lastVars)
+}
+
+func TestGetImportUsersNoPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/import", nil)
+
+	err := importUsers(nil, nil, &mockAuditLogger{}, &mockRolePolicy{})(sirius.PermissionSet{}, w, r)
+	assert.Equal(StatusError(http.StatusForbidden), err)
+}
+
+func TestPostImportUsersPreview(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockImportUsersClient{}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r := newImportUsersUpload(importUsersCSV)
+
+	err := importUsers(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusOK, w.Result().StatusCode)
+
+	assert.Equal(1, client.roles.count)
+	assert.Equal(getContext(r), client.roles.lastCtx)
+	assert.Equal(0, client.addUser.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal(importUsersVars{
+		Path:          "/users/import",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		XSRFToken:     "abcde",
+		CSV:           importUsersCSV,
+		Valid:         1,
+		Users: []importUser{
+			{
+				Row:          1,
+				Email:        "one@opgtest.com",
+				Firstname:    "One",
+				Surname:      "Person",
+				Organisation: "OPG User",
+				Roles:        []string{"System Admin", "Manager"},
+			},
+			{
+				Row:          2,
+				Email:        "two@opgtest.com",
+				Surname:      "Person",
+				Organisation: "Somewhere",
+				Roles:        []string{"Other"},
+				Errors: sirius.ValidationErrors{
+					"firstname":    {"isEmpty": "Enter a first name"},
+					"organisation": {"notInArray": "Organisation must be \"OPG User\" or \"COP User\""},
+					"roles":        {"notInArray": "Unknown roles: Other"},
+				},
+			},
+		},
+	}, template.lastVars)
+}
+
+func TestPostImportUsersPreviewDuplicateEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockImportUsersClient{}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r := newImportUsersUpload("Email,Firstname,Surname,Organisation,Roles\na@opgtest.com,A,B,COP User,\nA@opgtest.com,A,B,COP User,\n")
+
+	err := importUsers(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	vars := template.lastVars.(importUsersVars)
+	assert.Equal(1, vars.Valid)
+	assert.Nil(vars.Users[0].Errors)
+	assert.Equal(sirius.ValidationErrors{
+		"email": {"emailAddressDuplicated": "Email address appears more than once in the file"},
+	}, vars.Users[1].Errors)
+}
+
+func TestPostImportUsersBadFile(t *testing.T) {
+	for name, tc := range map[string]struct {
+		request *http.Request
+		message string
+	}{
+		"missing": {
+			request: func() *http.Request {
+				r, _ := http.NewRequest("POST", "/users/import", strings.NewReader("xsrfToken=abcde"))
+				r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			}(),
+			message: "Select a CSV file to upload",
+		},
+		"empty": {
+			request: newImportUsersUpload(""),
+			message: "The selected file is empty",
+		},
+		"missing columns": {
+			request: newImportUsersUpload("email,firstname\n"),
+			message: "The selected file must have the columns email, firstname, surname, organisation, roles",
+		},
+		"no users": {
+			request: newImportUsersUpload("email,firstname,surname,organisation,roles\n,,,,\n"),
+			message: "The selected file does not contain any users",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockImportUsersClient{}
+			template := &mockTemplate{}
+
+			w := httptest.NewRecorder()
+
+			err := importUsers(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, tc.request)
+			assert.Nil(err)
+
+			assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+			assert.Equal(0, client.roles.count)
+			assert.Equal(0, client.addUser.count)
+
+			assert.Equal(1, template.count)
+			assert.Equal(importUsersVars{
+				Path:          "/users/import",
+				Organisations: []sirius.Organisation{"OPG User", "COP User"},
+				XSRFToken:     "abcde",
+				Errors: sirius.ValidationErrors{
+					"file": {
+						"": tc.message,
+					},
+				},
+			}, template.lastVars)
+		})
+	}
+}
+
+func TestPostImportUsersConfirm(t *testing.T) {
+	assert := assert.New(t)
+
+	content := importUsersCSV + "three@opgtest.com,Three,Person,COP User,\nfour@opgtest.com,Four,Person,COP User,\n"
+
+	client := &mockImportUsersClient{}
+	client.addUser.err = map[string]error{
+		"three@opgtest.com": sirius.ValidationError{
+			Errors: sirius.ValidationErrors{
+				"email": {"emailAddressExists": "Email address is already in use"},
+			},
+		},
+	}
+	template := &mockTemplate{}
+
+	form := url.Values{
+		"xsrfToken": {"abcde"},
+		"csv":       {content},
+		"confirm":   {"confirm"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/users/import", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := importUsers(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(3, client.addUser.count)
+	assert.Equal(getContext(r), client.addUser.lastCtx)
+	assert.Equal([]string{"one@opgtest.com", "three@opgtest.com", "four@opgtest.com"}, client.addUser.lastEmail)
+	assert.Equal([]string{"System Admin", "Manager"}, client.addUser.lastRoles[0])
+
+	vars := template.lastVars.(importUsersVars)
+	assert.True(vars.Confirmed)
+	assert.Equal(3, vars.Valid)
+	assert.Equal(2, vars.Added)
+	assert.True(vars.Users[0].Added)
+	assert.False(vars.Users[1].Added)
+	assert.False(vars.Users[2].Added)
+	assert.Equal(sirius.ValidationErrors{
+		"email": {"emailAddressExists": "Email address is already in use"},
+	}, vars.Users[2].Errors)
+	assert.True(vars.Users[3].Added)
+}
+
+func TestPostImportUsersConfirmOtherError(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockImportUsersClient{}
+	client.addUser.err = map[string]error{
+		"one@opgtest.com": errors.New("oops"),
+	}
+	template := &mockTemplate{}
+
+	form := url.Values{
+		"csv":     {importUsersCSV},
+		"confirm": {"confirm"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/users/import", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := importUsers(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	vars := template.lastVars.(importUsersVars)
+	assert.Equal(0, vars.Added)
+	assert.Equal(sirius.ValidationErrors{"": {"": "oops"}}, vars.Users[0].Errors)
+}
+
+func TestPostImportUsersConfirmUnauthorized(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockImportUsersClient{}
+	client.addUser.err = map[string]error{
+		"one@opgtest.com": sirius.ErrUnauthorized,
+	}
+	template := &mockTemplate{}
+
+	form := url.Values{
+		"csv":     {importUsersCSV},
+		"confirm": {"confirm"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/users/import", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := importUsers(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Equal(sirius.ErrUnauthorized, err)
+	assert.Equal(0, template.count)
+}
+
+func TestPostImportUsersRolesError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedError := errors.New("oops")
+
+	client := &mockImportUsersClient{}
+	client.roles.err = expectedError
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r := newImportUsersUpload(importUsersCSV)
+
+	err := importUsers(client, template, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Equal(expectedError, err)
+
+	assert.Equal(0, client.addUser.count)
+	assert.Equal(0, template.count)
+}
+
+func TestPutImportUsers(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockImportUsersClient{}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", "/users/import", nil)
+
+	err := importUsers(client, nil, &mockAuditLogger{}, &mockRolePolicy{})(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
+}
+
+func TestPostImportUsersRolePolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockImportUsersClient{}
+	policy := &mockRolePolicy{data: map[string]string{"exclusive": "A user cannot have both System Admin and Manager"}}
+	template := &mockTemplate{}
+
+	form := url.Values{
+		"csv":     {importUsersCSV},
+		"confirm": {"confirm"},
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/users/import", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := importUsers(client, template, &mockAuditLogger{}, policy)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(2, policy.count)
+	assert.Equal(sirius.Organisation("Somewhere"), policy.lastOrganisation)
+	assert.Equal(0, client.addUser.count)
+
+	vars := template.lastVars.(importUsersVars)
+	assert.Equal(0, vars.Valid)
+	assert.Equal(sirius.ValidationErrors{
+		"roles": {"exclusive": "A user cannot have both System Admin and Manager"},
+	}, vars.Users[0].Errors)
+	assert.Equal(map[string]string{
+		"notInArray": "Unknown roles: Other",
+		"exclusive":  "A user cannot have both System Admin and Manager",
+	}, vars.Users[1].Errors["roles"])
+}
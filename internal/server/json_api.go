@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+// jsonResponseWriter marks a response as being for a client that asked for
+// JSON, so that jsonTemplate knows to encode the vars rather than render them.
+type jsonResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func newJSONResponseWriter(w http.ResponseWriter) *jsonResponseWriter {
+	w.Header().Set("Content-Type", "application/json")
+
+	return &jsonResponseWriter{ResponseWriter: w}
+}
+
+func (w *jsonResponseWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *jsonResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+type jsonTemplate struct {
+	Template
+}
+
+func (t jsonTemplate) ExecuteTemplate(w io.Writer, name string, vars interface{}) error {
+	jw, ok := w.(*jsonResponseWriter)
+	if !ok {
+		return t.Template.ExecuteTemplate(w, name, vars)
+	}
+
+	if !jw.wroteHeader && hasValidationErrors(vars) {
+		jw.WriteHeader(http.StatusBadRequest)
+	}
+
+	return json.NewEncoder(jw).Encode(vars)
+}
+
+type jsonRedirect struct {
+	Location string `json:"location"`
+}
+
+// writeJSONRedirect tells a client that asked for JSON where the result of its
+// request can be found, rather than redirecting it to a page.
+func writeJSONRedirect(w http.ResponseWriter, location string) {
+	w.Header().Set("Location", location)
+	_ = json.NewEncoder(w).Encode(jsonRedirect{Location: location})
+}
+
+func wantsJSON(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == "application/json" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasValidationErrors(vars interface{}) bool {
+	v := reflect.Indirect(reflect.ValueOf(vars))
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+
+	field := v.FieldByName("Errors")
+	if !field.IsValid() || !field.CanInterface() {
+		return false
+	}
+
+	errors, ok := field.Interface().(sirius.ValidationErrors)
+	return ok && len(errors) > 0
+}
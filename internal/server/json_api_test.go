@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	template := &mockTemplate{}
+	w := newJSONResponseWriter(httptest.NewRecorder())
+
+	err := jsonTemplate{template}.ExecuteTemplate(w, "page", listUsersVars{Path: "/users", Search: "milo"})
+	assert.Nil(err)
+
+	assert.Equal(0, template.count)
+
+	resp := w.ResponseWriter.(*httptest.ResponseRecorder)
+	assert.Equal(http.StatusOK, resp.Code)
+	assert.Equal("application/json", resp.Header().Get("Content-Type"))
+	assert.Contains(resp.Body.String(), `"Path":"/users","Users":null,"Search":"milo"`)
+}
+
+func TestJSONTemplateValidationErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	w := newJSONResponseWriter(httptest.NewRecorder())
+
+	err := jsonTemplate{&mockTemplate{}}.ExecuteTemplate(w, "page", addUserVars{
+		Errors: sirius.ValidationErrors{"email": {"isEmpty": "Enter an email"}},
+	})
+	assert.Nil(err)
+
+	resp := w.ResponseWriter.(*httptest.ResponseRecorder)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+	assert.Contains(resp.Body.String(), `"Errors":{"email":{"isEmpty":"Enter an email"}}`)
+}
+
+func TestJSONTemplateKeepsStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	w := newJSONResponseWriter(httptest.NewRecorder())
+	w.WriteHeader(http.StatusNotFound)
+
+	err := jsonTemplate{&mockTemplate{}}.ExecuteTemplate(w, "page", addUserVars{
+		Errors: sirius.ValidationErrors{"email": {"isEmpty": "Enter an email"}},
+	})
+	assert.Nil(err)
+
+	assert.Equal(http.StatusNotFound, w.ResponseWriter.(*httptest.ResponseRecorder).Code)
+}
+
+func TestJSONTemplateHTML(t *testing.T) {
+	assert := assert.New(t)
+
+	template := &mockTemplate{}
+	vars := listUsersVars{Path: "/users"}
+
+	err := jsonTemplate{template}.ExecuteTemplate(httptest.NewRecorder(), "page", vars)
+	assert.Nil(err)
+
+	assert.Equal(1, template.count)
+	assert.Equal("page", template.lastName)
+	assert.Equal(vars, template.lastVars)
+}
+
+func TestWantsJSON(t *testing.T) {
+	for accept, expected := range map[string]bool{
+		"":                                  false,
+		"text/html":                         false,
+		"application/json":                  true,
+		"application/json; charset=utf-8":   true,
+		"text/html, application/json;q=0.9": true,
+		"application/jsonx":                 false,
+	} {
+		t.Run(accept, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/path", nil)
+			r.Header.Set("Accept", accept)
+
+			assert.Equal(t, expected, wantsJSON(r))
+		})
+	}
+}
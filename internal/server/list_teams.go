@@ -2,20 +2,29 @@ package server
 
 import (
 	"net/http"
+	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
+const teamsPerPage = 25
+
 type ListTeamsClient interface {
 	Teams(sirius.Context) ([]sirius.Team, error)
+	TeamTypes(sirius.Context) ([]sirius.RefDataTeamType, error)
 }
 
 type listTeamsVars struct {
-	Path      string
-	XSRFToken string
-	Search    string
-	Teams     []sirius.Team
+	Path       string
+	XSRFToken  string
+	Search     string
+	Type       string
+	TeamTypes  []sirius.RefDataTeamType
+	Sort       teamsSort
+	Teams      []sirius.Team
+	Pagination pagination
 }
 
 func listTeams(client ListTeamsClient, tmpl Template) Handler {
@@ -35,27 +44,164 @@ func listTeams(client ListTeamsClient, tmpl Template) Handler {
 			return err
 		}
 
-		search := r.FormValue("search")
-		if search != "" {
-			searchLower := strings.ToLower(search)
-
-			var matchingTeams []sirius.Team
-			for _, t := range teams {
-				if strings.Contains(strings.ToLower(t.DisplayName), searchLower) {
-					matchingTeams = append(matchingTeams, t)
-				}
-			}
-
-			teams = matchingTeams
+		teamTypes, err := client.TeamTypes(ctx)
+		if err != nil {
+			return err
 		}
 
 		vars := listTeamsVars{
 			Path:      r.URL.Path,
 			XSRFToken: ctx.XSRFToken,
-			Search:    search,
-			Teams:     teams,
+			Search:    r.FormValue("search"),
+			Type:      r.FormValue("type"),
+			TeamTypes: teamTypes,
+		}
+
+		query := url.Values{}
+		for k, v := range map[string]string{
+			"search": vars.Search,
+			"type":   vars.Type,
+			"sort":   r.FormValue("sort"),
+			"page":   r.FormValue("page"),
+		} {
+			if v != "" {
+				query.Set(k, v)
+			}
+		}
+
+		vars.Sort = teamsSort{Current: r.FormValue("sort"), Query: query}
+
+		teams = filterTeams(teams, vars.Search, vars.Type)
+		sortTeams(teams, vars.Sort.Current)
+
+		vars.Pagination = newPagination(query, teamsPerPage, len(teams))
+		if len(teams) > 0 {
+			vars.Teams = teams[vars.Pagination.From()-1 : vars.Pagination.To()]
 		}
 
 		return tmpl.ExecuteTemplate(w, "page", vars)
 	}
 }
+
+// filterTeams keeps the teams whose name or a member's email contains search,
+// ignoring case. A teamType of "lpa" or "supervision" matches every team in
+// that service, otherwise it must match the team's type exactly.
+func filterTeams(teams []sirius.Team, search, teamType string) []sirius.Team {
+	search = strings.ToLower(search)
+
+	var filtered []sirius.Team
+
+	for _, team := range teams {
+		switch teamType {
+		case "":
+		case "lpa":
+			if team.Type != "" {
+				continue
+			}
+		case "supervision":
+			if team.Type == "" {
+				continue
+			}
+		default:
+			if team.Type != teamType {
+				continue
+			}
+		}
+
+		if search != "" && !strings.Contains(strings.ToLower(team.DisplayName), search) && !hasMemberEmail(team, search) {
+			continue
+		}
+
+		filtered = append(filtered, team)
+	}
+
+	return filtered
+}
+
+func hasMemberEmail(team sirius.Team, search string) bool {
+	for _, member := range team.Members {
+		if strings.Contains(strings.ToLower(member.Email), search) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortTeams orders teams by a column of the list, where a "-" prefix reverses
+// the order. Teams are sorted by name by default, and within the same type or
+// number of members.
+func sortTeams(teams []sirius.Team, by string) {
+	desc := strings.HasPrefix(by, "-")
+	by = strings.TrimPrefix(by, "-")
+
+	byName := func(i, j int) bool {
+		return strings.ToLower(teams[i].DisplayName) < strings.ToLower(teams[j].DisplayName)
+	}
+
+	less := byName
+	switch by {
+	case "type":
+		less = func(i, j int) bool {
+			if teams[i].TypeLabel == teams[j].TypeLabel {
+				return byName(i, j)
+			}
+			return teams[i].TypeLabel < teams[j].TypeLabel
+		}
+	case "members":
+		less = func(i, j int) bool {
+			if len(teams[i].Members) == len(teams[j].Members) {
+				return byName(i, j)
+			}
+			return len(teams[i].Members) < len(teams[j].Members)
+		}
+	}
+
+	sort.SliceStable(teams, func(i, j int) bool {
+		if desc {
+			return less(j, i)
+		}
+		return less(i, j)
+	})
+}
+
+// teamsSort gives the links for the sortable columns of the list of teams.
+type teamsSort struct {
+	Current string
+	Query   url.Values
+}
+
+// URL returns the query string to sort by the column, reversing the order if
+// the list is already sorted by it. The page is reset as it would no longer
+// show the same teams.
+func (s teamsSort) URL(column string) string {
+	query := url.Values{}
+	for k, v := range s.Query {
+		query[k] = v
+	}
+
+	query.Del("page")
+	if s.Current == column || (s.Current == "" && column == "name") {
+		query.Set("sort", "-"+column)
+	} else {
+		query.Set("sort", column)
+	}
+
+	return "?" + query.Encode()
+}
+
+// Direction gives the value of aria-sort for the column.
+func (s teamsSort) Direction(column string) string {
+	switch s.Current {
+	case column:
+		return "ascending"
+	case "-" + column:
+		return "descending"
+	case "":
+		if column == "name" {
+			return "ascending"
+		}
+	}
+
+	return "none"
+}
@@ -2,8 +2,10 @@ package server
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
@@ -11,17 +13,32 @@ import (
 )
 
 type mockListTeamsClient struct {
-	count   int
-	lastCtx sirius.Context
-	err     error
-	data    []sirius.Team
+	teams struct {
+		count   int
+		lastCtx sirius.Context
+		err     error
+		data    []sirius.Team
+	}
+	teamTypes struct {
+		count   int
+		lastCtx sirius.Context
+		err     error
+		data    []sirius.RefDataTeamType
+	}
 }
 
 func (m *mockListTeamsClient) Teams(ctx sirius.Context) ([]sirius.Team, error) {
-	m.count += 1
-	m.lastCtx = ctx
+	m.teams.count += 1
+	m.teams.lastCtx = ctx
+
+	return m.teams.data, m.teams.err
+}
+
+func (m *mockListTeamsClient) TeamTypes(ctx sirius.Context) ([]sirius.RefDataTeamType, error) {
+	m.teamTypes.count += 1
+	m.teamTypes.lastCtx = ctx
 
-	return m.data, m.err
+	return m.teamTypes.data, m.teamTypes.err
 }
 
 func (m *mockListTeamsClient) requiredPermissions() sirius.PermissionSet {
@@ -39,9 +56,10 @@ func TestListTeams(t *testing.T) {
 			Type:        "Top Notch",
 		},
 	}
-	client := &mockListTeamsClient{
-		data: data,
-	}
+	teamTypes := []sirius.RefDataTeamType{{Handle: "ALLOCATIONS", Label: "Allocations"}}
+	client := &mockListTeamsClient{}
+	client.teams.data = data
+	client.teamTypes.data = teamTypes
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
@@ -52,15 +70,20 @@ func TestListTeams(t *testing.T) {
 
 	resp := w.Result()
 	assert.Equal(http.StatusOK, resp.StatusCode)
-	assert.Equal(getContext(r), client.lastCtx)
+	assert.Equal(getContext(r), client.teams.lastCtx)
+	assert.Equal(getContext(r), client.teamTypes.lastCtx)
 
-	assert.Equal(1, client.count)
+	assert.Equal(1, client.teams.count)
+	assert.Equal(1, client.teamTypes.count)
 
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(listTeamsVars{
-		Path:  "/path",
-		Teams: data,
+		Path:       "/path",
+		TeamTypes:  teamTypes,
+		Sort:       teamsSort{Query: url.Values{}},
+		Teams:      data,
+		Pagination: newPagination(url.Values{}, teamsPerPage, 1),
 	}, template.lastVars)
 }
 
@@ -91,9 +114,8 @@ func TestListTeamsSearch(t *testing.T) {
 			Type:        "Terrible",
 		},
 	}
-	client := &mockListTeamsClient{
-		data: data,
-	}
+	client := &mockListTeamsClient{}
+	client.teams.data = data
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
@@ -104,15 +126,19 @@ func TestListTeamsSearch(t *testing.T) {
 
 	resp := w.Result()
 	assert.Equal(http.StatusOK, resp.StatusCode)
-	assert.Equal(getContext(r), client.lastCtx)
+	assert.Equal(getContext(r), client.teams.lastCtx)
 
-	assert.Equal(1, client.count)
+	assert.Equal(1, client.teams.count)
+
+	query := url.Values{"search": {"milo"}}
 
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(listTeamsVars{
-		Path:   "/path",
-		Search: "milo",
+		Path:       "/path",
+		Search:     "milo",
+		Sort:       teamsSort{Query: query},
+		Pagination: newPagination(query, teamsPerPage, 1),
 		Teams: []sirius.Team{
 			{
 				ID:          29,
@@ -128,7 +154,8 @@ func TestListTeamsError(t *testing.T) {
 	assert := assert.New(t)
 
 	expectedErr := errors.New("err")
-	client := &mockListTeamsClient{err: expectedErr}
+	client := &mockListTeamsClient{}
+	client.teams.err = expectedErr
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
@@ -140,6 +167,23 @@ func TestListTeamsError(t *testing.T) {
 	assert.Equal(0, template.count)
 }
 
+func TestListTeamsTeamTypesError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("err")
+	client := &mockListTeamsClient{}
+	client.teamTypes.err = expectedErr
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+
+	err := listTeams(client, template)(client.requiredPermissions(), w, r)
+
+	assert.Equal(expectedErr, err)
+	assert.Equal(0, template.count)
+}
+
 func TestPostListTeams(t *testing.T) {
 	assert := assert.New(t)
 
@@ -150,3 +194,152 @@ func TestPostListTeams(t *testing.T) {
 	err := listTeams(nil, nil)(client.requiredPermissions(), w, r)
 	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
 }
+
+func TestListTeamsSearchMemberEmail(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockListTeamsClient{}
+	client.teams.data = []sirius.Team{
+		{ID: 1, DisplayName: "Cool Team", Members: []sirius.TeamMember{{Email: "someone@opgtest.com"}}},
+		{ID: 2, DisplayName: "Other Team", Members: []sirius.TeamMember{{Email: "other@opgtest.com"}}},
+		{ID: 3, DisplayName: "Someone's Team"},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?search=SOMEONE", nil)
+
+	err := listTeams(client, template)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	vars := template.lastVars.(listTeamsVars)
+	assert.Equal([]sirius.Team{client.teams.data[0], client.teams.data[2]}, vars.Teams)
+}
+
+func TestListTeamsType(t *testing.T) {
+	teams := []sirius.Team{
+		{ID: 1, DisplayName: "LPA Team", TypeLabel: "LPA"},
+		{ID: 2, DisplayName: "Allocations Team", Type: "ALLOCATIONS", TypeLabel: "Supervision — Allocations"},
+		{ID: 3, DisplayName: "Finance Team", Type: "FINANCE", TypeLabel: "Supervision — Finance"},
+	}
+
+	testCases := map[string][]int{
+		"":            {2, 3, 1},
+		"lpa":         {1},
+		"supervision": {2, 3},
+		"FINANCE":     {3},
+		"OTHER":       nil,
+	}
+
+	for teamType, expected := range testCases {
+		t.Run(teamType, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockListTeamsClient{}
+			client.teams.data = teams
+			template := &mockTemplate{}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/path?type="+teamType, nil)
+
+			err := listTeams(client, template)(client.requiredPermissions(), w, r)
+			assert.Nil(err)
+
+			vars := template.lastVars.(listTeamsVars)
+			assert.Equal(teamType, vars.Type)
+
+			var ids []int
+			for _, team := range vars.Teams {
+				ids = append(ids, team.ID)
+			}
+			assert.Equal(expected, ids)
+		})
+	}
+}
+
+func TestListTeamsSort(t *testing.T) {
+	teams := []sirius.Team{
+		{ID: 1, DisplayName: "b", TypeLabel: "LPA", Members: make([]sirius.TeamMember, 1)},
+		{ID: 2, DisplayName: "C", TypeLabel: "Supervision — Allocations", Members: make([]sirius.TeamMember, 3)},
+		{ID: 3, DisplayName: "a", TypeLabel: "LPA", Members: make([]sirius.TeamMember, 2)},
+		{ID: 4, DisplayName: "d", TypeLabel: "LPA", Members: make([]sirius.TeamMember, 1)},
+	}
+
+	testCases := map[string][]int{
+		"":         {3, 1, 2, 4},
+		"name":     {3, 1, 2, 4},
+		"-name":    {4, 2, 1, 3},
+		"type":     {3, 1, 4, 2},
+		"-type":    {2, 4, 1, 3},
+		"members":  {1, 4, 3, 2},
+		"-members": {2, 3, 4, 1},
+	}
+
+	for sort, expected := range testCases {
+		t.Run(sort, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockListTeamsClient{}
+			client.teams.data = append([]sirius.Team{}, teams...)
+			template := &mockTemplate{}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/path?sort="+sort, nil)
+
+			err := listTeams(client, template)(client.requiredPermissions(), w, r)
+			assert.Nil(err)
+
+			vars := template.lastVars.(listTeamsVars)
+			assert.Equal(sort, vars.Sort.Current)
+
+			var ids []int
+			for _, team := range vars.Teams {
+				ids = append(ids, team.ID)
+			}
+			assert.Equal(expected, ids)
+		})
+	}
+}
+
+func TestListTeamsPaginates(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockListTeamsClient{}
+	client.teams.data = make([]sirius.Team, teamsPerPage+5)
+	for i := range client.teams.data {
+		client.teams.data[i] = sirius.Team{ID: i, DisplayName: fmt.Sprintf("Team %03d", i)}
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?type=lpa&page=2", nil)
+
+	err := listTeams(client, template)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	vars := template.lastVars.(listTeamsVars)
+	assert.Equal(2, vars.Pagination.Page)
+	assert.Equal(2, vars.Pagination.Pages())
+	assert.Equal(client.teams.data[teamsPerPage:], vars.Teams)
+	assert.Equal("?type=lpa", vars.Pagination.URL(1))
+}
+
+func TestTeamsSort(t *testing.T) {
+	assert := assert.New(t)
+
+	query := url.Values{"search": {"a"}, "page": {"2"}}
+
+	unsorted := teamsSort{Query: query}
+	assert.Equal("?search=a&sort=-name", unsorted.URL("name"))
+	assert.Equal("?search=a&sort=members", unsorted.URL("members"))
+	assert.Equal("ascending", unsorted.Direction("name"))
+	assert.Equal("none", unsorted.Direction("members"))
+
+	desc := teamsSort{Current: "-members", Query: query}
+	assert.Equal("?search=a&sort=members", desc.URL("members"))
+	assert.Equal("?search=a&sort=name", desc.URL("name"))
+	assert.Equal("descending", desc.Direction("members"))
+	assert.Equal("none", desc.Direction("name"))
+
+	assert.Equal([]string{"2"}, query["page"])
+}
@@ -2,22 +2,44 @@ package server
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
+const usersPerPage = 25
+
+// maxRoleFilterUsers limits how many users can be filtered by organisation or
+// role, as each one has to be fetched from Sirius.
+const maxRoleFilterUsers = 50
+
 type ListUsersClient interface {
 	SearchUsers(sirius.Context, string) ([]sirius.User, error)
+	Organisations() []sirius.Organisation
+	Roles(sirius.Context) ([]string, error)
+	User(sirius.Context, int) (sirius.AuthUser, error)
 }
 
 type listUsersVars struct {
-	Path   string
-	Users  []sirius.User
-	Search string
-	Errors sirius.ValidationErrors
+	Path          string
+	Users         []sirius.User
+	Search        string
+	Status        string
+	Organisation  string
+	Organisations []sirius.Organisation
+	Role          string
+	ReviewDue     bool
+	ReviewDates   map[int]time.Time
+	Roles         []string
+	Pagination    pagination
+	Errors        sirius.ValidationErrors
 }
 
-func listUsers(client ListUsersClient, tmpl Template) Handler {
+func listUsers(client ListUsersClient, tmpl Template, suspensions Suspensions) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-users", http.MethodPut) {
 			return StatusError(http.StatusForbidden)
@@ -27,15 +49,42 @@ func listUsers(client ListUsersClient, tmpl Template) Handler {
 			return StatusError(http.StatusMethodNotAllowed)
 		}
 
-		search := r.FormValue("search")
+		ctx := getContext(r)
+
+		roles, err := client.Roles(ctx)
+		if err != nil {
+			return err
+		}
 
 		vars := listUsersVars{
-			Path:   r.URL.Path,
-			Search: search,
+			Path:          r.URL.Path,
+			Search:        r.FormValue("search"),
+			Status:        r.FormValue("status"),
+			Organisation:  r.FormValue("organisation"),
+			Organisations: client.Organisations(),
+			Role:          r.FormValue("role"),
+			ReviewDue:     r.FormValue("review") == "due",
+			Roles:         roles,
+		}
+
+		query := url.Values{}
+		for k, v := range map[string]string{
+			"search":       vars.Search,
+			"status":       vars.Status,
+			"organisation": vars.Organisation,
+			"role":         vars.Role,
+			"review":       r.FormValue("review"),
+			"page":         r.FormValue("page"),
+		} {
+			if v != "" {
+				query.Set(k, v)
+			}
 		}
 
-		if search != "" {
-			users, err := client.SearchUsers(getContext(r), search)
+		var users []sirius.User
+
+		if vars.Search != "" {
+			users, err = client.SearchUsers(ctx, vars.Search)
 
 			if _, ok := err.(sirius.ClientError); ok {
 				vars.Errors = sirius.ValidationErrors{
@@ -46,10 +95,186 @@ func listUsers(client ListUsersClient, tmpl Template) Handler {
 			} else if err != nil {
 				return err
 			} else {
-				vars.Users = users
+				users = filterUsers(users, vars.Status, "", "")
+
+				if vars.Organisation != "" || vars.Role != "" {
+					if len(users) > maxRoleFilterUsers {
+						users = nil
+						vars.Errors = sirius.ValidationErrors{
+							"search": {
+								"": "Too many users match the search to filter by organisation or role, enter a more specific search term",
+							},
+						}
+					} else {
+						users, err = withRoles(ctx, client, users)
+						if err != nil {
+							return err
+						}
+
+						users = filterUsers(users, "", vars.Organisation, vars.Role)
+					}
+				}
+			}
+		} else if !vars.ReviewDue && (vars.Status != "" || vars.Organisation != "" || vars.Role != "") {
+			vars.Errors = sirius.ValidationErrors{
+				"search": {
+					"": "Enter a search term to filter users",
+				},
 			}
 		}
 
+		if vars.ReviewDue && vars.Errors == nil {
+			due, err := suspensions.ReviewDue(time.Now())
+			if err != nil {
+				return err
+			}
+
+			vars.ReviewDates = map[int]time.Time{}
+			for _, suspension := range due {
+				vars.ReviewDates[suspension.UserID] = *suspension.ReviewDate
+			}
+
+			if vars.Search != "" {
+				users = filterReviewDue(users, vars.ReviewDates)
+			} else {
+				users, err = reviewDueUsers(ctx, client, due)
+				if err != nil {
+					return err
+				}
+
+				users = filterUsers(users, vars.Status, vars.Organisation, vars.Role)
+			}
+		}
+
+		vars.Pagination = newPagination(query, usersPerPage, len(users))
+		if len(users) > 0 {
+			vars.Users = users[vars.Pagination.From()-1 : vars.Pagination.To()]
+		}
+
 		return tmpl.ExecuteTemplate(w, "page", vars)
 	}
 }
+
+// filterUsers keeps the order of users, as it has already been sorted by the
+// client, and an empty filter matches every user.
+func filterUsers(users []sirius.User, status, organisation, role string) []sirius.User {
+	var filtered []sirius.User
+
+	for _, user := range users {
+		if status != "" && user.Status.String() != status {
+			continue
+		}
+
+		if organisation != "" && user.Organisation.String() != organisation {
+			continue
+		}
+
+		if role != "" && !containsString(user.Roles, role) {
+			continue
+		}
+
+		filtered = append(filtered, user)
+	}
+
+	return filtered
+}
+
+// filterReviewDue keeps the users that are due for review and still suspended,
+// as they may have been reactivated in Sirius since being recorded.
+func filterReviewDue(users []sirius.User, reviewDates map[int]time.Time) []sirius.User {
+	var filtered []sirius.User
+
+	for _, user := range users {
+		if _, ok := reviewDates[user.ID]; ok && user.Status == "Suspended" {
+			filtered = append(filtered, user)
+		}
+	}
+
+	return filtered
+}
+
+// reviewDueUsers lists suspensions that are due for review without a search.
+// Each user is fetched from Sirius, so that those who have since been
+// reactivated or deleted outside of this service are left out.
+func reviewDueUsers(ctx sirius.Context, client ListUsersClient, due []history.Suspension) ([]sirius.User, error) {
+	var users []sirius.User
+
+	for _, suspension := range due {
+		user, err := client.User(ctx, suspension.UserID)
+		if status, ok := err.(sirius.StatusError); ok && status.Code == http.StatusNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !user.Suspended {
+			continue
+		}
+
+		users = append(users, sirius.User{
+			ID:           user.ID,
+			DisplayName:  user.Firstname + " " + user.Surname,
+			Email:        user.Email,
+			Status:       "Suspended",
+			Organisation: user.Organisation,
+			Roles:        user.Roles,
+		})
+	}
+
+	return users, nil
+}
+
+// withRoles fetches the organisation and roles of each user, as they are not
+// part of search results. Users deleted since the search are left out.
+func withRoles(ctx sirius.Context, client ListUsersClient, users []sirius.User) ([]sirius.User, error) {
+	var result []sirius.User
+
+	for _, user := range users {
+		details, err := client.User(ctx, user.ID)
+		if status, ok := err.(sirius.StatusError); ok && status.Code == http.StatusNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		user.Organisation = details.Organisation
+		user.Roles = details.Roles
+		result = append(result, user)
+	}
+
+	return result, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsOrganisation(list []sirius.Organisation, o sirius.Organisation) bool {
+	for _, v := range list {
+		if v == o {
+			return true
+		}
+	}
+
+	return false
+}
+
+// joinOrganisations lists organisations for a message, as `"A", "B" or "C"`.
+func joinOrganisations(organisations []sirius.Organisation) string {
+	quoted := make([]string, len(organisations))
+	for i, organisation := range organisations {
+		quoted[i] = strconv.Quote(organisation.String())
+	}
+
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
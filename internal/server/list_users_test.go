@@ -4,26 +4,61 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 	"github.com/stretchr/testify/assert"
 )
 
 type mockListUsersClient struct {
-	count      int
-	lastCtx    sirius.Context
-	lastSearch string
-	err        error
-	data       []sirius.User
+	searchUsers struct {
+		count      int
+		lastCtx    sirius.Context
+		lastSearch string
+		err        error
+		data       []sirius.User
+	}
+	roles struct {
+		count   int
+		lastCtx sirius.Context
+		err     error
+		data    []string
+	}
+	user struct {
+		count   int
+		lastCtx sirius.Context
+		err     map[int]error
+		data    map[int]sirius.AuthUser
+	}
 }
 
 func (m *mockListUsersClient) SearchUsers(ctx sirius.Context, search string) ([]sirius.User, error) {
-	m.count += 1
-	m.lastCtx = ctx
-	m.lastSearch = search
+	m.searchUsers.count += 1
+	m.searchUsers.lastCtx = ctx
+	m.searchUsers.lastSearch = search
+
+	return m.searchUsers.data, m.searchUsers.err
+}
+
+func (m *mockListUsersClient) Roles(ctx sirius.Context) ([]string, error) {
+	m.roles.count += 1
+	m.roles.lastCtx = ctx
 
-	return m.data, m.err
+	return m.roles.data, m.roles.err
+}
+
+func (m *mockListUsersClient) User(ctx sirius.Context, id int) (sirius.AuthUser, error) {
+	m.user.count += 1
+	m.user.lastCtx = ctx
+
+	return m.user.data[id], m.user.err[id]
+}
+
+func (m *mockListUsersClient) Organisations() []sirius.Organisation {
+	return []sirius.Organisation{"OPG User", "COP User"}
 }
 
 func (m *mockListUsersClient) requiredPermissions() sirius.PermissionSet {
@@ -33,7 +68,8 @@ func (m *mockListUsersClient) requiredPermissions() sirius.PermissionSet {
 func TestListUsers(t *testing.T) {
 	assert := assert.New(t)
 
-	data := []sirius.User{
+	client := &mockListUsersClient{}
+	client.searchUsers.data = []sirius.User{
 		{
 			ID:          29,
 			DisplayName: "Milo Nihei",
@@ -41,31 +77,34 @@ func TestListUsers(t *testing.T) {
 			Status:      "Active",
 		},
 	}
-	client := &mockListUsersClient{
-		data: data,
-	}
+	client.roles.data = []string{"System Admin"}
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path?search=milo", nil)
 
-	handler := listUsers(client, template)
+	handler := listUsers(client, template, &mockSuspensions{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Nil(err)
 
 	resp := w.Result()
 	assert.Equal(http.StatusOK, resp.StatusCode)
-	assert.Equal(getContext(r), client.lastCtx)
+	assert.Equal(getContext(r), client.searchUsers.lastCtx)
+
+	assert.Equal(1, client.searchUsers.count)
+	assert.Equal("milo", client.searchUsers.lastSearch)
 
-	assert.Equal(1, client.count)
-	assert.Equal("milo", client.lastSearch)
+	assert.Equal(1, client.roles.count)
+	assert.Equal(getContext(r), client.roles.lastCtx)
 
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(listUsersVars{
-		Path:   "/path",
-		Search: "milo",
+		Path:          "/path",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Search:        "milo",
+		Roles:         []string{"System Admin"},
 		Users: []sirius.User{
 			{
 				ID:          29,
@@ -74,23 +113,327 @@ func TestListUsers(t *testing.T) {
 				Status:      "Active",
 			},
 		},
+		Pagination: pagination{
+			Page:    1,
+			PerPage: 25,
+			Total:   1,
+			Query:   url.Values{"search": {"milo"}},
+		},
 	}, template.lastVars)
 }
 
+func TestListUsersFiltered(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockListUsersClient{}
+	client.searchUsers.data = []sirius.User{
+		{ID: 1, Status: "Active"},
+		{ID: 2, Status: "Locked"},
+		{ID: 3, Status: "Active"},
+		{ID: 4, Status: "Active"},
+		{ID: 5, Status: "Active"},
+		{ID: 6, Status: "Active"},
+	}
+	client.user.data = map[int]sirius.AuthUser{
+		1: {ID: 1, Organisation: "OPG User", Roles: []string{"Manager"}},
+		2: {ID: 2, Organisation: "OPG User", Roles: []string{"Manager"}},
+		3: {ID: 3, Organisation: "COP User", Roles: []string{"Manager"}},
+		4: {ID: 4, Organisation: "OPG User", Roles: []string{"System Admin"}},
+		5: {ID: 5, Organisation: "OPG User", Roles: []string{"System Admin", "Manager"}},
+	}
+	client.user.err = map[int]error{
+		6: sirius.StatusError{Code: http.StatusNotFound},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?search=milo&status=Active&organisation=OPG+User&role=Manager", nil)
+
+	err := listUsers(client, template, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(5, client.user.count)
+	assert.Equal(getContext(r), client.user.lastCtx)
+
+	vars := template.lastVars.(listUsersVars)
+	assert.Equal("Active", vars.Status)
+	assert.Equal("OPG User", vars.Organisation)
+	assert.Equal("Manager", vars.Role)
+	assert.Equal([]sirius.User{
+		{ID: 1, Status: "Active", Organisation: "OPG User", Roles: []string{"Manager"}},
+		{ID: 5, Status: "Active", Organisation: "OPG User", Roles: []string{"System Admin", "Manager"}},
+	}, vars.Users)
+	assert.Equal(2, vars.Pagination.Total)
+}
+
+func TestListUsersFilteredByStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockListUsersClient{}
+	client.searchUsers.data = []sirius.User{
+		{ID: 1, Status: "Active"},
+		{ID: 2, Status: "Locked"},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?search=milo&status=Locked", nil)
+
+	err := listUsers(client, template, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(0, client.user.count)
+	assert.Equal([]sirius.User{client.searchUsers.data[1]}, template.lastVars.(listUsersVars).Users)
+}
+
+func TestListUsersFilteredTooMany(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockListUsersClient{}
+	for i := 0; i <= maxRoleFilterUsers; i++ {
+		client.searchUsers.data = append(client.searchUsers.data, sirius.User{ID: i, Status: "Active"})
+	}
+	client.searchUsers.data = append(client.searchUsers.data, sirius.User{ID: 100, Status: "Locked"})
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?search=milo&role=Manager", nil)
+
+	err := listUsers(client, template, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(0, client.user.count)
+
+	vars := template.lastVars.(listUsersVars)
+	assert.Nil(vars.Users)
+	assert.Equal(sirius.ValidationErrors{
+		"search": {"": "Too many users match the search to filter by organisation or role, enter a more specific search term"},
+	}, vars.Errors)
+
+	r, _ = http.NewRequest("GET", "/path?search=milo&role=Manager&status=Locked", nil)
+
+	err = listUsers(client, template, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(1, client.user.count)
+	assert.Nil(template.lastVars.(listUsersVars).Errors)
+}
+
+func TestListUsersFilteredUserError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("oops")
+
+	client := &mockListUsersClient{}
+	client.searchUsers.data = []sirius.User{{ID: 1, Status: "Active"}}
+	client.user.err = map[int]error{1: expectedErr}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?search=milo&role=Manager", nil)
+
+	err := listUsers(client, template, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Equal(expectedErr, err)
+	assert.Equal(0, template.count)
+}
+
+func TestListUsersFilteredWithoutSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockListUsersClient{}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?status=Active&role=Manager", nil)
+
+	err := listUsers(client, template, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(0, client.searchUsers.count)
+	assert.Equal(0, client.user.count)
+
+	vars := template.lastVars.(listUsersVars)
+	assert.Nil(vars.Users)
+	assert.Equal(sirius.ValidationErrors{
+		"search": {"": "Enter a search term to filter users"},
+	}, vars.Errors)
+}
+
+func TestListUsersPaginated(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockListUsersClient{}
+	for i := 1; i <= 60; i++ {
+		client.searchUsers.data = append(client.searchUsers.data, sirius.User{ID: i, Status: "Active"})
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?search=milo&status=Active&page=3", nil)
+
+	err := listUsers(client, template, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	vars := template.lastVars.(listUsersVars)
+	assert.Len(vars.Users, 10)
+	assert.Equal(51, vars.Users[0].ID)
+	assert.Equal(3, vars.Pagination.Page)
+	assert.Equal(60, vars.Pagination.Total)
+	assert.Equal("?page=2&search=milo&status=Active", vars.Pagination.URL(vars.Pagination.Previous()))
+}
+
+func TestListUsersReviewDue(t *testing.T) {
+	assert := assert.New(t)
+
+	reviewDate := time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	client := &mockListUsersClient{}
+	client.user.data = map[int]sirius.AuthUser{
+		2: {ID: 2, Firstname: "Suspended", Surname: "User", Email: "suspended@opgtest.com", Organisation: "OPG User", Roles: []string{"Manager"}, Suspended: true},
+		3: {ID: 3, Firstname: "Reactivated", Surname: "User"},
+	}
+	client.user.err = map[int]error{
+		4: sirius.StatusError{Code: http.StatusNotFound},
+	}
+	suspensions := &mockSuspensions{}
+	suspensions.reviewDue.data = []history.Suspension{
+		{UserID: 2, UserName: "Old Name", UserEmail: "old@opgtest.com", Suspended: true, ReviewDate: &reviewDate},
+		{UserID: 3, Suspended: true, ReviewDate: &reviewDate},
+		{UserID: 4, Suspended: true, ReviewDate: &reviewDate},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?review=due", nil)
+
+	err := listUsers(client, template, suspensions)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(0, client.searchUsers.count)
+	assert.Equal(3, client.user.count)
+	assert.Equal(getContext(r), client.user.lastCtx)
+	assert.Equal(1, suspensions.reviewDue.count)
+	assert.WithinDuration(time.Now(), suspensions.reviewDue.lastNow, time.Second)
+
+	vars := template.lastVars.(listUsersVars)
+	assert.True(vars.ReviewDue)
+	assert.Equal(map[int]time.Time{2: reviewDate, 3: reviewDate, 4: reviewDate}, vars.ReviewDates)
+	assert.Equal([]sirius.User{
+		{ID: 2, DisplayName: "Suspended User", Email: "suspended@opgtest.com", Status: "Suspended", Organisation: "OPG User", Roles: []string{"Manager"}},
+	}, vars.Users)
+	assert.Equal("?review=due", vars.Pagination.URL(1))
+}
+
+func TestListUsersReviewDueWithSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	reviewDate := time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	client := &mockListUsersClient{}
+	client.searchUsers.data = []sirius.User{
+		{ID: 1, Status: "Active"},
+		{ID: 2, Status: "Suspended"},
+		{ID: 3, Status: "Active"},
+	}
+	suspensions := &mockSuspensions{}
+	suspensions.reviewDue.data = []history.Suspension{
+		{UserID: 2, Suspended: true, ReviewDate: &reviewDate},
+		{UserID: 3, Suspended: true, ReviewDate: &reviewDate},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?search=milo&review=due", nil)
+
+	err := listUsers(client, template, suspensions)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	vars := template.lastVars.(listUsersVars)
+	assert.Equal([]sirius.User{client.searchUsers.data[1]}, vars.Users)
+}
+
+func TestListUsersReviewDueFilters(t *testing.T) {
+	assert := assert.New(t)
+
+	reviewDate := time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	client := &mockListUsersClient{}
+	client.user.data = map[int]sirius.AuthUser{
+		2: {ID: 2, Organisation: "OPG User", Suspended: true},
+		3: {ID: 3, Organisation: "COP User", Suspended: true},
+	}
+	suspensions := &mockSuspensions{}
+	suspensions.reviewDue.data = []history.Suspension{
+		{UserID: 2, Suspended: true, ReviewDate: &reviewDate},
+		{UserID: 3, Suspended: true, ReviewDate: &reviewDate},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?review=due&organisation=COP+User", nil)
+
+	err := listUsers(client, template, suspensions)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	vars := template.lastVars.(listUsersVars)
+	assert.Len(vars.Users, 1)
+	assert.Equal(3, vars.Users[0].ID)
+}
+
+func TestListUsersReviewDueUserError(t *testing.T) {
+	assert := assert.New(t)
+
+	reviewDate := time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC)
+	expectedErr := errors.New("oops")
+
+	client := &mockListUsersClient{}
+	client.user.err = map[int]error{2: expectedErr}
+	suspensions := &mockSuspensions{}
+	suspensions.reviewDue.data = []history.Suspension{
+		{UserID: 2, Suspended: true, ReviewDate: &reviewDate},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?review=due", nil)
+
+	err := listUsers(client, template, suspensions)(client.requiredPermissions(), w, r)
+	assert.Equal(expectedErr, err)
+	assert.Equal(0, template.count)
+}
+
+func TestListUsersReviewDueError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("oops")
+	client := &mockListUsersClient{}
+	suspensions := &mockSuspensions{}
+	suspensions.reviewDue.err = expectedErr
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path?review=due", nil)
+
+	err := listUsers(client, template, suspensions)(client.requiredPermissions(), w, r)
+	assert.Equal(expectedErr, err)
+	assert.Equal(0, template.count)
+}
+
 func TestListUsersNoPermission(t *testing.T) {
 	assert := assert.New(t)
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := listUsers(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := listUsers(nil, nil, nil)(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
 func TestListUsersRequiresSearch(t *testing.T) {
 	assert := assert.New(t)
 
-	data := []sirius.User{
+	client := &mockListUsersClient{}
+	client.searchUsers.data = []sirius.User{
 		{
 			ID:          29,
 			DisplayName: "Milo Nihei",
@@ -98,15 +441,12 @@ func TestListUsersRequiresSearch(t *testing.T) {
 			Status:      "Active",
 		},
 	}
-	client := &mockListUsersClient{
-		data: data,
-	}
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	handler := listUsers(client, template)
+	handler := listUsers(client, template, &mockSuspensions{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Nil(err)
@@ -114,29 +454,34 @@ func TestListUsersRequiresSearch(t *testing.T) {
 	resp := w.Result()
 	assert.Equal(http.StatusOK, resp.StatusCode)
 
-	assert.Equal(0, client.count)
+	assert.Equal(0, client.searchUsers.count)
 
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(listUsersVars{
-		Path:   "/path",
-		Search: "",
-		Users:  nil,
+		Path:          "/path",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Search:        "",
+		Users:         nil,
+		Pagination: pagination{
+			Page:    1,
+			PerPage: 25,
+			Query:   url.Values{},
+		},
 	}, template.lastVars)
 }
 
 func TestListUsersClientError(t *testing.T) {
 	assert := assert.New(t)
 
-	client := &mockListUsersClient{
-		err: sirius.ClientError("problem"),
-	}
+	client := &mockListUsersClient{}
+	client.searchUsers.err = sirius.ClientError("problem")
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path?search=m", nil)
 
-	handler := listUsers(client, template)
+	handler := listUsers(client, template, &mockSuspensions{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Nil(err)
@@ -144,14 +489,20 @@ func TestListUsersClientError(t *testing.T) {
 	resp := w.Result()
 	assert.Equal(http.StatusOK, resp.StatusCode)
 
-	assert.Equal(1, client.count)
+	assert.Equal(1, client.searchUsers.count)
 
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(listUsersVars{
-		Path:   "/path",
-		Search: "m",
-		Users:  nil,
+		Path:          "/path",
+		Organisations: []sirius.Organisation{"OPG User", "COP User"},
+		Search:        "m",
+		Users:         nil,
+		Pagination: pagination{
+			Page:    1,
+			PerPage: 25,
+			Query:   url.Values{"search": {"m"}},
+		},
 		Errors: sirius.ValidationErrors{
 			"search": {
 				"": "problem",
@@ -164,19 +515,38 @@ func TestListUsersSiriusErrors(t *testing.T) {
 	assert := assert.New(t)
 
 	expectedErr := errors.New("err")
-	client := &mockListUsersClient{err: expectedErr}
+	client := &mockListUsersClient{}
+	client.searchUsers.err = expectedErr
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/?search=long", nil)
 
-	handler := listUsers(client, template)
+	handler := listUsers(client, template, &mockSuspensions{})
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Equal(expectedErr, err)
 	assert.Equal(0, template.count)
 }
 
+func TestListUsersRolesError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("err")
+	client := &mockListUsersClient{}
+	client.roles.err = expectedErr
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/?search=long", nil)
+
+	err := listUsers(client, template, &mockSuspensions{})(client.requiredPermissions(), w, r)
+
+	assert.Equal(expectedErr, err)
+	assert.Equal(0, client.searchUsers.count)
+	assert.Equal(0, template.count)
+}
+
 func TestPostListUsers(t *testing.T) {
 	assert := assert.New(t)
 	template := &mockTemplate{}
@@ -185,7 +555,7 @@ func TestPostListUsers(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "", nil)
 
-	handler := listUsers(nil, template)
+	handler := listUsers(nil, template, nil)
 	err := handler(client.requiredPermissions(), w, r)
 
 	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
@@ -0,0 +1,24 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentRoute(t *testing.T) {
+	assert := assert.New(t)
+
+	handler := instrumentRoute("/test-route", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	r, _ := http.NewRequest("POST", "/test-route", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(float64(2), testutil.ToFloat64(requestsTotal.WithLabelValues("/test-route", "post", "418")))
+}
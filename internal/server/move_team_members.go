@@ -0,0 +1,194 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+type MoveTeamMembersClient interface {
+	Team(sirius.Context, int) (sirius.Team, error)
+	Teams(sirius.Context) ([]sirius.Team, error)
+	EditTeam(sirius.Context, sirius.Team) error
+}
+
+type moveTeamMembersVars struct {
+	Path      string
+	XSRFToken string
+	Team      sirius.Team
+	Hash      string
+	Teams     []sirius.Team
+	Selected  map[int]string
+	TargetID  int
+	Conflict  *teamConflict
+	Errors    sirius.ValidationErrors
+}
+
+func moveTeamMembers(client MoveTeamMembersClient, tmpl Template, audit AuditLogger) Handler {
+	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		if !perm.HasPermission("v1-teams", http.MethodPut) {
+			return StatusError(http.StatusForbidden)
+		}
+
+		if r.Method != http.MethodPost {
+			return StatusError(http.StatusMethodNotAllowed)
+		}
+
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/teams/move-members/"))
+		if err != nil {
+			return StatusError(http.StatusNotFound)
+		}
+
+		if err := r.ParseForm(); err != nil {
+			return StatusError(http.StatusBadRequest)
+		}
+
+		ctx := getContext(r)
+
+		team, err := client.Team(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		teams, err := client.Teams(ctx)
+		if err != nil {
+			return err
+		}
+
+		vars := moveTeamMembersVars{
+			Path:      r.URL.Path,
+			XSRFToken: ctx.XSRFToken,
+			Team:      team,
+			Hash:      r.PostFormValue("hash"),
+			Selected:  make(map[int]string),
+		}
+
+		for _, t := range teams {
+			if t.ID != team.ID {
+				vars.Teams = append(vars.Teams, t)
+			}
+		}
+
+		for _, id := range r.PostForm["selected[]"] {
+			userID, err := strconv.Atoi(id)
+			if err != nil {
+				return StatusError(http.StatusBadRequest)
+			}
+
+			for _, user := range team.Members {
+				if userID == user.ID {
+					vars.Selected[userID] = user.DisplayName
+				}
+			}
+		}
+
+		if len(vars.Selected) == 0 {
+			vars.Errors = sirius.ValidationErrors{
+				"selected": {
+					"": "Select the users to move",
+				},
+			}
+
+			w.WriteHeader(http.StatusBadRequest)
+			return tmpl.ExecuteTemplate(w, "page", vars)
+		}
+
+		if r.PostFormValue("confirm") == "" {
+			return tmpl.ExecuteTemplate(w, "page", vars)
+		}
+
+		vars.TargetID, _ = strconv.Atoi(r.PostFormValue("team"))
+		if vars.TargetID == 0 || vars.TargetID == team.ID {
+			vars.Errors = sirius.ValidationErrors{
+				"team": {
+					"": "Select a team to move the users to",
+				},
+			}
+
+			w.WriteHeader(http.StatusBadRequest)
+			return tmpl.ExecuteTemplate(w, "page", vars)
+		}
+
+		target, err := client.Team(ctx, vars.TargetID)
+		if err != nil {
+			return err
+		}
+
+		source := team
+		source.Members = nil
+
+		inTarget := map[int]bool{}
+		for _, member := range target.Members {
+			inTarget[member.ID] = true
+		}
+
+		updatedTarget := target
+		updatedTarget.Members = append([]sirius.TeamMember(nil), target.Members...)
+
+		for _, member := range team.Members {
+			if _, ok := vars.Selected[member.ID]; !ok {
+				source.Members = append(source.Members, member)
+			} else if !inTarget[member.ID] {
+				updatedTarget.Members = append(updatedTarget.Members, member)
+			}
+		}
+
+		if vars.Hash != teamHash(team) {
+			vars.Conflict = newTeamConflict(r, team, source)
+			w.WriteHeader(http.StatusConflict)
+			return tmpl.ExecuteTemplate(w, "page", vars)
+		}
+
+		err = client.EditTeam(ctx, source)
+		auditMoveTeamMembers(audit, r, team, source, err)
+
+		if err == nil {
+			err = client.EditTeam(ctx, updatedTarget)
+			auditMoveTeamMembers(audit, r, target, updatedTarget, err)
+
+			// put the members back in the source team, so they are not left
+			// without either team
+			if err != nil {
+				if rollbackErr := client.EditTeam(ctx, team); rollbackErr != nil {
+					auditMoveTeamMembers(audit, r, source, team, rollbackErr)
+					return fmt.Errorf("could not restore team %d after failing to move members: %w", team.ID, rollbackErr)
+				}
+
+				auditMoveTeamMembers(audit, r, source, team, nil)
+			}
+		}
+
+		if _, ok := err.(sirius.ClientError); ok {
+			vars.Errors = sirius.ValidationErrors{
+				"_": {
+					"": err.Error(),
+				},
+			}
+			w.WriteHeader(http.StatusBadRequest)
+		} else if verr, ok := err.(*sirius.ValidationError); ok {
+			vars.Errors = verr.Errors
+			w.WriteHeader(http.StatusBadRequest)
+		} else if err != nil {
+			return err
+		} else {
+			return RedirectError(fmt.Sprintf("/teams/%d", target.ID))
+		}
+
+		return tmpl.ExecuteTemplate(w, "page", vars)
+	}
+}
+
+func auditMoveTeamMembers(audit AuditLogger, r *http.Request, before, after sirius.Team, err error) {
+	audit.Audit(r, logging.AuditEvent{
+		Action:     "move-team-members",
+		TargetType: "team",
+		TargetID:   before.ID,
+		Before:     before,
+		After:      after,
+		Err:        err,
+	})
+}
@@ -0,0 +1,382 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMoveTeamMembersClient struct {
+	team struct {
+		count  int
+		lastID []int
+		data   map[int]sirius.Team
+		err    error
+	}
+	teams struct {
+		count   int
+		lastCtx sirius.Context
+		data    []sirius.Team
+		err     error
+	}
+	editTeam struct {
+		count    int
+		lastCtx  sirius.Context
+		lastTeam []sirius.Team
+		err      []error
+	}
+}
+
+func (c *mockMoveTeamMembersClient) Team(ctx sirius.Context, id int) (sirius.Team, error) {
+	c.team.count += 1
+	c.team.lastID = append(c.team.lastID, id)
+
+	return c.team.data[id], c.team.err
+}
+
+func (c *mockMoveTeamMembersClient) Teams(ctx sirius.Context) ([]sirius.Team, error) {
+	c.teams.count += 1
+	c.teams.lastCtx = ctx
+
+	return c.teams.data, c.teams.err
+}
+
+func (c *mockMoveTeamMembersClient) EditTeam(ctx sirius.Context, team sirius.Team) error {
+	c.editTeam.count += 1
+	c.editTeam.lastCtx = ctx
+	c.editTeam.lastTeam = append(c.editTeam.lastTeam, team)
+
+	if len(c.editTeam.err) >= c.editTeam.count {
+		return c.editTeam.err[c.editTeam.count-1]
+	}
+
+	return nil
+}
+
+func (c *mockMoveTeamMembersClient) requiredPermissions() sirius.PermissionSet {
+	return sirius.PermissionSet{"v1-teams": sirius.PermissionGroup{Permissions: []string{"put"}}}
+}
+
+var (
+	moveSourceTeam = sirius.Team{
+		ID:          1,
+		DisplayName: "Source",
+		Members: []sirius.TeamMember{
+			{ID: 10, DisplayName: "Anne"},
+			{ID: 11, DisplayName: "Bob"},
+			{ID: 12, DisplayName: "Carl"},
+		},
+	}
+	moveTargetTeam = sirius.Team{
+		ID:          2,
+		DisplayName: "Target",
+		Members: []sirius.TeamMember{
+			{ID: 11, DisplayName: "Bob"},
+			{ID: 13, DisplayName: "Dee"},
+		},
+	}
+)
+
+func newMockMoveTeamMembersClient() *mockMoveTeamMembersClient {
+	client := &mockMoveTeamMembersClient{}
+	client.team.data = map[int]sirius.Team{1: moveSourceTeam, 2: moveTargetTeam}
+	client.teams.data = []sirius.Team{{ID: 1, DisplayName: "Source"}, {ID: 2, DisplayName: "Target"}}
+
+	return client
+}
+
+func postMoveTeamMembers(form url.Values) *http.Request {
+	form.Set("xsrfToken", "abcde")
+	form["selected[]"] = []string{"10", "11"}
+
+	r, _ := http.NewRequest("POST", "/teams/move-members/1", strings.NewReader(form.Encode()))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestPostMoveTeamMembers(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockMoveTeamMembersClient()
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r := postMoveTeamMembers(url.Values{})
+
+	err := moveTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal([]int{1}, client.team.lastID)
+	assert.Equal(1, client.teams.count)
+	assert.Equal(getContext(r), client.teams.lastCtx)
+	assert.Equal(0, client.editTeam.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal("page", template.lastName)
+	assert.Equal(moveTeamMembersVars{
+		Path:      "/teams/move-members/1",
+		XSRFToken: "abcde",
+		Team:      moveSourceTeam,
+		Teams:     []sirius.Team{{ID: 2, DisplayName: "Target"}},
+		Selected:  map[int]string{10: "Anne", 11: "Bob"},
+	}, template.lastVars)
+}
+
+func TestPostMoveTeamMembersNoPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/teams/move-members/1", nil)
+
+	err := moveTeamMembers(nil, nil, nil)(sirius.PermissionSet{}, w, r)
+	assert.Equal(StatusError(http.StatusForbidden), err)
+}
+
+func TestPostMoveTeamMembersBadPath(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockMoveTeamMembersClient()
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/teams/move-members/abc", nil)
+
+	err := moveTeamMembers(client, nil, nil)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusNotFound), err)
+}
+
+func TestPostMoveTeamMembersConfirm(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockMoveTeamMembersClient()
+	template := &mockTemplate{}
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r := postMoveTeamMembers(url.Values{"team": {"2"}, "confirm": {"confirm"}, "hash": {teamHash(moveSourceTeam)}})
+
+	err := moveTeamMembers(client, template, audit)(client.requiredPermissions(), w, r)
+	assert.Equal(RedirectError("/teams/2"), err)
+
+	assert.Equal([]int{1, 2}, client.team.lastID)
+	assert.Equal(getContext(r), client.editTeam.lastCtx)
+	assert.Equal([]sirius.Team{
+		{
+			ID:          1,
+			DisplayName: "Source",
+			Members:     []sirius.TeamMember{{ID: 12, DisplayName: "Carl"}},
+		},
+		{
+			ID:          2,
+			DisplayName: "Target",
+			Members: []sirius.TeamMember{
+				{ID: 11, DisplayName: "Bob"},
+				{ID: 13, DisplayName: "Dee"},
+				{ID: 10, DisplayName: "Anne"},
+			},
+		},
+	}, client.editTeam.lastTeam)
+
+	assert.Equal(0, template.count)
+
+	assert.Equal(2, audit.count)
+	assert.Equal(1, audit.events[0].TargetID)
+	assert.Equal(2, audit.events[1].TargetID)
+	assert.Equal("move-team-members", audit.lastEvent().Action)
+}
+
+func TestPostMoveTeamMembersNoTarget(t *testing.T) {
+	for name, target := range map[string]string{
+		"missing": "",
+		"same":    "1",
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := newMockMoveTeamMembersClient()
+			template := &mockTemplate{}
+
+			w := httptest.NewRecorder()
+			r := postMoveTeamMembers(url.Values{"team": {target}, "confirm": {"confirm"}, "hash": {teamHash(moveSourceTeam)}})
+
+			err := moveTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+			assert.Nil(err)
+
+			assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+			assert.Equal(0, client.editTeam.count)
+			assert.Equal(sirius.ValidationErrors{
+				"team": {"": "Select a team to move the users to"},
+			}, template.lastVars.(moveTeamMembersVars).Errors)
+		})
+	}
+}
+
+func TestPostMoveTeamMembersNoneSelected(t *testing.T) {
+	for name, values := range map[string]url.Values{
+		"select":  {},
+		"confirm": {"team": {"2"}, "confirm": {"confirm"}, "hash": {teamHash(moveSourceTeam)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := newMockMoveTeamMembersClient()
+			template := &mockTemplate{}
+			audit := &mockAuditLogger{}
+
+			r, _ := http.NewRequest("POST", "/teams/move-members/1", strings.NewReader(values.Encode()))
+			r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+			w := httptest.NewRecorder()
+
+			err := moveTeamMembers(client, template, audit)(client.requiredPermissions(), w, r)
+			assert.Nil(err)
+
+			assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+			assert.Equal(0, client.editTeam.count)
+			assert.Equal(0, audit.count)
+			assert.Equal(sirius.ValidationErrors{
+				"selected": {"": "Select the users to move"},
+			}, template.lastVars.(moveTeamMembersVars).Errors)
+		})
+	}
+}
+
+func TestPostMoveTeamMembersConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockMoveTeamMembersClient()
+	template := &mockTemplate{}
+
+	seen := moveSourceTeam
+	seen.DisplayName = "Old name"
+
+	w := httptest.NewRecorder()
+	r := postMoveTeamMembers(url.Values{"team": {"2"}, "confirm": {"confirm"}, "hash": {teamHash(seen)}})
+
+	err := moveTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusConflict, w.Result().StatusCode)
+	assert.Equal(0, client.editTeam.count)
+
+	assert.Equal(&teamConflict{
+		Hash: teamHash(moveSourceTeam),
+		Fields: url.Values{
+			"team":       {"2"},
+			"confirm":    {"confirm"},
+			"selected[]": {"10", "11"},
+		},
+		Left: []sirius.TeamMember{
+			{ID: 10, DisplayName: "Anne"},
+			{ID: 11, DisplayName: "Bob"},
+		},
+	}, template.lastVars.(moveTeamMembersVars).Conflict)
+}
+
+func TestPostMoveTeamMembersSourceFails(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockMoveTeamMembersClient()
+	client.editTeam.err = []error{sirius.ClientError("problem")}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r := postMoveTeamMembers(url.Values{"team": {"2"}, "confirm": {"confirm"}, "hash": {teamHash(moveSourceTeam)}})
+
+	err := moveTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(1, client.editTeam.count)
+
+	vars := template.lastVars.(moveTeamMembersVars)
+	assert.Equal(2, vars.TargetID)
+	assert.Equal(sirius.ValidationErrors{"_": {"": "problem"}}, vars.Errors)
+}
+
+func TestPostMoveTeamMembersTargetFailsRollsBack(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockMoveTeamMembersClient()
+	client.editTeam.err = []error{nil, &sirius.ValidationError{
+		Errors: sirius.ValidationErrors{"members": {"": "problem"}},
+	}}
+	template := &mockTemplate{}
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r := postMoveTeamMembers(url.Values{"team": {"2"}, "confirm": {"confirm"}, "hash": {teamHash(moveSourceTeam)}})
+
+	err := moveTeamMembers(client, template, audit)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(3, client.editTeam.count)
+	assert.Equal(moveSourceTeam, client.editTeam.lastTeam[2])
+
+	assert.Equal(sirius.ValidationErrors{"members": {"": "problem"}}, template.lastVars.(moveTeamMembersVars).Errors)
+
+	assert.Equal(3, audit.count)
+	assert.Equal(moveSourceTeam, audit.lastEvent().After)
+}
+
+func TestPostMoveTeamMembersRollbackFails(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("oops")
+
+	client := newMockMoveTeamMembersClient()
+	client.editTeam.err = []error{nil, errors.New("target"), expectedErr}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r := postMoveTeamMembers(url.Values{"team": {"2"}, "confirm": {"confirm"}, "hash": {teamHash(moveSourceTeam)}})
+
+	err := moveTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.True(errors.Is(err, expectedErr))
+	assert.Equal("could not restore team 1 after failing to move members: oops", err.Error())
+
+	assert.Equal(3, client.editTeam.count)
+	assert.Equal(0, template.count)
+}
+
+func TestPostMoveTeamMembersErrors(t *testing.T) {
+	expectedErr := errors.New("oops")
+
+	for name, setup := range map[string]func(*mockMoveTeamMembersClient){
+		"Team":     func(c *mockMoveTeamMembersClient) { c.team.err = expectedErr },
+		"Teams":    func(c *mockMoveTeamMembersClient) { c.teams.err = expectedErr },
+		"EditTeam": func(c *mockMoveTeamMembersClient) { c.editTeam.err = []error{expectedErr} },
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := newMockMoveTeamMembersClient()
+			setup(client)
+			template := &mockTemplate{}
+
+			w := httptest.NewRecorder()
+			r := postMoveTeamMembers(url.Values{"team": {"2"}, "confirm": {"confirm"}, "hash": {teamHash(moveSourceTeam)}})
+
+			err := moveTeamMembers(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+			assert.Equal(expectedErr, err)
+			assert.Equal(0, template.count)
+		})
+	}
+}
+
+func TestGetMoveTeamMembers(t *testing.T) {
+	assert := assert.New(t)
+
+	client := newMockMoveTeamMembersClient()
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/teams/move-members/1", nil)
+
+	err := moveTeamMembers(nil, nil, nil)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
+}
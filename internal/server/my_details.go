@@ -17,7 +17,7 @@ type myDetailsVars struct {
 	Surname            string
 	Email              string
 	PhoneNumber        string
-	Organisation       string
+	Organisation       sirius.Organisation
 	Roles              []string
 	Teams              []string
 	CanEditPhoneNumber bool
@@ -45,17 +45,11 @@ func myDetails(client MyDetailsClient, tmpl Template) Handler {
 			Surname:            myDetails.Surname,
 			Email:              myDetails.Email,
 			PhoneNumber:        myDetails.PhoneNumber,
+			Organisation:       myDetails.Organisation,
+			Roles:              myDetails.Roles,
 			CanEditPhoneNumber: canEditPhoneNumber,
 		}
 
-		for _, role := range myDetails.Roles {
-			if role == "OPG User" || role == "COP User" {
-				vars.Organisation = role
-			} else {
-				vars.Roles = append(vars.Roles, role)
-			}
-		}
-
 		for _, team := range myDetails.Teams {
 			vars.Teams = append(vars.Teams, team.DisplayName)
 		}
@@ -28,12 +28,13 @@ func TestGetMyDetails(t *testing.T) {
 	assert := assert.New(t)
 
 	data := sirius.MyDetails{
-		ID:          123,
-		Firstname:   "John",
-		Surname:     "Doe",
-		Email:       "john@doe.com",
-		PhoneNumber: "123",
-		Roles:       []string{"A", "COP User", "B"},
+		ID:           123,
+		Firstname:    "John",
+		Surname:      "Doe",
+		Email:        "john@doe.com",
+		PhoneNumber:  "123",
+		Organisation: "COP User",
+		Roles:        []string{"A", "B"},
 		Teams: []sirius.MyDetailsTeam{
 			{DisplayName: "A Team"},
 		},
@@ -75,12 +76,13 @@ func TestGetMyDetailsUsesPermission(t *testing.T) {
 	assert := assert.New(t)
 
 	data := sirius.MyDetails{
-		ID:          123,
-		Firstname:   "John",
-		Surname:     "Doe",
-		Email:       "john@doe.com",
-		PhoneNumber: "123",
-		Roles:       []string{"A", "COP User", "B"},
+		ID:           123,
+		Firstname:    "John",
+		Surname:      "Doe",
+		Email:        "john@doe.com",
+		PhoneNumber:  "123",
+		Organisation: "COP User",
+		Roles:        []string{"A", "B"},
 		Teams: []sirius.MyDetailsTeam{
 			{DisplayName: "A Team"},
 		},
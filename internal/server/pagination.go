@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/url"
+	"strconv"
+)
+
+type pagination struct {
+	Page    int
+	PerPage int
+	Total   int
+	Query   url.Values
+}
+
+func newPagination(query url.Values, perPage, total int) pagination {
+	p := pagination{
+		Page:    1,
+		PerPage: perPage,
+		Total:   total,
+		Query:   query,
+	}
+
+	if page, err := strconv.Atoi(query.Get("page")); err == nil && page > 1 {
+		p.Page = page
+	}
+
+	if p.Page > p.Pages() {
+		p.Page = p.Pages()
+	}
+
+	return p
+}
+
+func (p pagination) Pages() int {
+	if p.Total == 0 {
+		return 1
+	}
+
+	return (p.Total + p.PerPage - 1) / p.PerPage
+}
+
+func (p pagination) From() int {
+	if p.Total == 0 {
+		return 0
+	}
+
+	return (p.Page-1)*p.PerPage + 1
+}
+
+func (p pagination) To() int {
+	if to := p.Page * p.PerPage; to < p.Total {
+		return to
+	}
+
+	return p.Total
+}
+
+func (p pagination) HasPrevious() bool {
+	return p.Page > 1
+}
+
+func (p pagination) HasNext() bool {
+	return p.Page < p.Pages()
+}
+
+func (p pagination) Previous() int {
+	return p.Page - 1
+}
+
+func (p pagination) Next() int {
+	return p.Page + 1
+}
+
+// URL returns the query string for the given page, keeping any other
+// parameters so that filters are not lost when moving between pages.
+func (p pagination) URL(page int) string {
+	query := url.Values{}
+	for k, v := range p.Query {
+		query[k] = v
+	}
+
+	query.Del("page")
+	if page > 1 {
+		query.Set("page", strconv.Itoa(page))
+	}
+
+	return "?" + query.Encode()
+}
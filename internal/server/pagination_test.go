@@ -0,0 +1,49 @@
+package server
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPagination(t *testing.T) {
+	for name, tc := range map[string]struct {
+		query                 string
+		total                 int
+		page, pages, from, to int
+		hasPrevious, hasNext  bool
+	}{
+		"empty":        {query: "", total: 0, page: 1, pages: 1, from: 0, to: 0},
+		"first":        {query: "", total: 60, page: 1, pages: 3, from: 1, to: 25, hasNext: true},
+		"middle":       {query: "page=2", total: 60, page: 2, pages: 3, from: 26, to: 50, hasPrevious: true, hasNext: true},
+		"last":         {query: "page=3", total: 60, page: 3, pages: 3, from: 51, to: 60, hasPrevious: true},
+		"past the end": {query: "page=9", total: 60, page: 3, pages: 3, from: 51, to: 60, hasPrevious: true},
+		"invalid":      {query: "page=x", total: 60, page: 1, pages: 3, from: 1, to: 25, hasNext: true},
+		"negative":     {query: "page=-1", total: 60, page: 1, pages: 3, from: 1, to: 25, hasNext: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			query, _ := url.ParseQuery(tc.query)
+			p := newPagination(query, 25, tc.total)
+
+			assert.Equal(tc.page, p.Page)
+			assert.Equal(tc.pages, p.Pages())
+			assert.Equal(tc.from, p.From())
+			assert.Equal(tc.to, p.To())
+			assert.Equal(tc.hasPrevious, p.HasPrevious())
+			assert.Equal(tc.hasNext, p.HasNext())
+		})
+	}
+}
+
+func TestPaginationURL(t *testing.T) {
+	assert := assert.New(t)
+
+	p := newPagination(url.Values{"search": {"a b"}, "page": {"2"}}, 25, 60)
+
+	assert.Equal("?search=a+b", p.URL(1))
+	assert.Equal("?page=3&search=a+b", p.URL(3))
+	assert.Equal(url.Values{"search": {"a b"}, "page": {"2"}}, p.Query)
+}
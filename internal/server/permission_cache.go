@@ -0,0 +1,191 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var permissionCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "permissions_cache_requests_total",
+	Help: "Number of permission lookups, by whether they were served from the cache.",
+}, []string{"result"})
+
+type permissionCacheEntry struct {
+	permissions sirius.PermissionSet
+	expires     time.Time
+}
+
+type currentUserCacheEntry struct {
+	myDetails sirius.MyDetails
+	expires   time.Time
+}
+
+// permissionCache wraps a Client so that MyPermissions is only called on
+// Sirius once per session within the ttl. Any change to a user flushes the
+// cache, so that their permissions are not stale for the remainder of the ttl.
+// Each session's user is kept in the same way, to log and audit who they are.
+type permissionCache struct {
+	Client
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]permissionCacheEntry
+	users   map[string]currentUserCacheEntry
+}
+
+func newPermissionCache(client Client, ttl time.Duration) *permissionCache {
+	permissionCacheRequests.WithLabelValues("hit")
+	permissionCacheRequests.WithLabelValues("miss")
+
+	return &permissionCache{
+		Client:  client,
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]permissionCacheEntry{},
+		users:   map[string]currentUserCacheEntry{},
+	}
+}
+
+func (c *permissionCache) MyPermissions(ctx sirius.Context) (sirius.PermissionSet, error) {
+	if c.ttl <= 0 {
+		return c.Client.MyPermissions(ctx)
+	}
+
+	key := permissionCacheKey(ctx)
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok && now.Before(entry.expires) {
+		permissionCacheRequests.WithLabelValues("hit").Inc()
+		return entry.permissions, nil
+	}
+
+	permissionCacheRequests.WithLabelValues("miss").Inc()
+
+	permissions, err := c.Client.MyPermissions(ctx)
+	if err != nil {
+		return permissions, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	c.entries[key] = permissionCacheEntry{
+		permissions: permissions,
+		expires:     now.Add(c.ttl),
+	}
+
+	return permissions, nil
+}
+
+// MyDetails records the session's user, so that CurrentUser does not need to
+// fetch them again.
+func (c *permissionCache) MyDetails(ctx sirius.Context) (sirius.MyDetails, error) {
+	myDetails, err := c.Client.MyDetails(ctx)
+	if err != nil || c.ttl <= 0 {
+		return myDetails, err
+	}
+
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.users {
+		if !now.Before(e.expires) {
+			delete(c.users, k)
+		}
+	}
+
+	c.users[permissionCacheKey(ctx)] = currentUserCacheEntry{
+		myDetails: myDetails,
+		expires:   now.Add(c.ttl),
+	}
+
+	return myDetails, nil
+}
+
+// CurrentUser returns the details of the user the session belongs to, which
+// may be up to the ttl old.
+func (c *permissionCache) CurrentUser(ctx sirius.Context) (sirius.MyDetails, error) {
+	if c.ttl > 0 {
+		c.mu.Lock()
+		entry, ok := c.users[permissionCacheKey(ctx)]
+		c.mu.Unlock()
+
+		if ok && c.now().Before(entry.expires) {
+			return entry.myDetails, nil
+		}
+	}
+
+	return c.MyDetails(ctx)
+}
+
+func (c *permissionCache) EditUser(ctx sirius.Context, user sirius.AuthUser) error {
+	err := c.Client.EditUser(ctx, user)
+	if err == nil {
+		c.Flush()
+	}
+
+	return err
+}
+
+func (c *permissionCache) DeleteUser(ctx sirius.Context, id int) error {
+	err := c.Client.DeleteUser(ctx, id)
+	if err == nil {
+		c.Flush()
+	}
+
+	return err
+}
+
+func (c *permissionCache) Invalidate(ctx sirius.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := permissionCacheKey(ctx)
+	delete(c.entries, key)
+	delete(c.users, key)
+}
+
+func (c *permissionCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]permissionCacheEntry{}
+	c.users = map[string]currentUserCacheEntry{}
+}
+
+func permissionCacheKey(ctx sirius.Context) string {
+	cookies := make([]string, len(ctx.Cookies))
+	for i, cookie := range ctx.Cookies {
+		cookies[i] = cookie.Name + "=" + cookie.Value
+	}
+	sort.Strings(cookies)
+
+	hash := sha256.New()
+	for _, cookie := range cookies {
+		hash.Write([]byte(cookie))
+		hash.Write([]byte{0})
+	}
+	hash.Write([]byte(ctx.XSRFToken))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,322 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockPermissionCacheClient struct {
+	Client
+
+	myPermissions struct {
+		count   int
+		lastCtx sirius.Context
+		data    sirius.PermissionSet
+		err     error
+	}
+	editUser struct {
+		count int
+		err   error
+	}
+	deleteUser struct {
+		count int
+		err   error
+	}
+	myDetails struct {
+		count int
+		data  sirius.MyDetails
+		err   error
+	}
+}
+
+func (m *mockPermissionCacheClient) MyDetails(ctx sirius.Context) (sirius.MyDetails, error) {
+	m.myDetails.count += 1
+
+	return m.myDetails.data, m.myDetails.err
+}
+
+func (m *mockPermissionCacheClient) MyPermissions(ctx sirius.Context) (sirius.PermissionSet, error) {
+	m.myPermissions.count += 1
+	m.myPermissions.lastCtx = ctx
+
+	return m.myPermissions.data, m.myPermissions.err
+}
+
+func (m *mockPermissionCacheClient) EditUser(ctx sirius.Context, user sirius.AuthUser) error {
+	m.editUser.count += 1
+
+	return m.editUser.err
+}
+
+func (m *mockPermissionCacheClient) DeleteUser(ctx sirius.Context, id int) error {
+	m.deleteUser.count += 1
+
+	return m.deleteUser.err
+}
+
+func permissionCacheContext(session string) sirius.Context {
+	return sirius.Context{
+		Cookies:   []*http.Cookie{{Name: "sirius", Value: session}, {Name: "XSRF-TOKEN", Value: "abcde"}},
+		XSRFToken: "abcde",
+	}
+}
+
+func TestPermissionCache(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	client.myPermissions.data = sirius.PermissionSet{"v1-users": {Permissions: []string{"put"}}}
+
+	now := time.Now()
+	cache := newPermissionCache(client, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		permissions, err := cache.MyPermissions(permissionCacheContext("one"))
+		assert.Nil(err)
+		assert.Equal(client.myPermissions.data, permissions)
+	}
+
+	assert.Equal(1, client.myPermissions.count)
+	assert.Equal(permissionCacheContext("one"), client.myPermissions.lastCtx)
+
+	_, _ = cache.MyPermissions(permissionCacheContext("two"))
+	assert.Equal(2, client.myPermissions.count)
+
+	now = now.Add(time.Minute)
+
+	_, _ = cache.MyPermissions(permissionCacheContext("one"))
+	assert.Equal(3, client.myPermissions.count)
+}
+
+func TestPermissionCacheCookieOrder(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	cache := newPermissionCache(client, time.Minute)
+
+	ctx := permissionCacheContext("one")
+	_, _ = cache.MyPermissions(ctx)
+
+	ctx.Cookies = []*http.Cookie{ctx.Cookies[1], ctx.Cookies[0]}
+	_, _ = cache.MyPermissions(ctx)
+
+	assert.Equal(1, client.myPermissions.count)
+
+	ctx.XSRFToken = "other"
+	_, _ = cache.MyPermissions(ctx)
+
+	assert.Equal(2, client.myPermissions.count)
+}
+
+func TestPermissionCacheDoesNotCacheErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedError := errors.New("oops")
+
+	client := &mockPermissionCacheClient{}
+	client.myPermissions.err = expectedError
+	cache := newPermissionCache(client, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.MyPermissions(permissionCacheContext("one"))
+		assert.Equal(expectedError, err)
+	}
+
+	assert.Equal(2, client.myPermissions.count)
+}
+
+func TestPermissionCacheDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	cache := newPermissionCache(client, 0)
+
+	for i := 0; i < 2; i++ {
+		_, _ = cache.MyPermissions(permissionCacheContext("one"))
+	}
+
+	assert.Equal(2, client.myPermissions.count)
+}
+
+func TestPermissionCacheInvalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	cache := newPermissionCache(client, time.Minute)
+
+	_, _ = cache.MyPermissions(permissionCacheContext("one"))
+	_, _ = cache.MyPermissions(permissionCacheContext("two"))
+
+	cache.Invalidate(permissionCacheContext("one"))
+
+	_, _ = cache.MyPermissions(permissionCacheContext("one"))
+	_, _ = cache.MyPermissions(permissionCacheContext("two"))
+
+	assert.Equal(3, client.myPermissions.count)
+}
+
+func TestPermissionCacheFlushedByUserChanges(t *testing.T) {
+	for name, change := range map[string]func(*permissionCache) error{
+		"EditUser":   func(c *permissionCache) error { return c.EditUser(sirius.Context{}, sirius.AuthUser{}) },
+		"DeleteUser": func(c *permissionCache) error { return c.DeleteUser(sirius.Context{}, 1) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockPermissionCacheClient{}
+			cache := newPermissionCache(client, time.Minute)
+
+			_, _ = cache.MyPermissions(permissionCacheContext("one"))
+			_, _ = cache.MyPermissions(permissionCacheContext("two"))
+
+			assert.Nil(change(cache))
+
+			_, _ = cache.MyPermissions(permissionCacheContext("one"))
+			_, _ = cache.MyPermissions(permissionCacheContext("two"))
+
+			assert.Equal(4, client.myPermissions.count)
+		})
+	}
+}
+
+func TestPermissionCacheNotFlushedByFailedChanges(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	client.editUser.err = errors.New("oops")
+	cache := newPermissionCache(client, time.Minute)
+
+	_, _ = cache.MyPermissions(permissionCacheContext("one"))
+	assert.Equal(client.editUser.err, cache.EditUser(sirius.Context{}, sirius.AuthUser{}))
+	_, _ = cache.MyPermissions(permissionCacheContext("one"))
+
+	assert.Equal(1, client.myPermissions.count)
+}
+
+func TestErrorHandlerInvalidatesPermissions(t *testing.T) {
+	for name, expectedError := range map[string]error{
+		"Forbidden":    StatusError(http.StatusForbidden),
+		"Unauthorized": sirius.ErrUnauthorized,
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockPermissionCacheClient{}
+			cache := newPermissionCache(client, time.Minute)
+
+			wrap := errorHandler(&mockLogger{}, cache, &mockTemplate{}, "/prefix", "http://sirius")
+			handler := wrap(func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+				return expectedError
+			})
+
+			r, _ := http.NewRequest("GET", "/path", nil)
+
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(2, client.myPermissions.count)
+		})
+	}
+}
+
+func TestPermissionCacheCurrentUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	client.myDetails.data = sirius.MyDetails{ID: 123}
+
+	now := time.Now()
+	cache := newPermissionCache(client, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		myDetails, err := cache.CurrentUser(permissionCacheContext("one"))
+		assert.Nil(err)
+		assert.Equal(client.myDetails.data, myDetails)
+	}
+
+	assert.Equal(1, client.myDetails.count)
+
+	_, _ = cache.MyDetails(permissionCacheContext("two"))
+	_, _ = cache.CurrentUser(permissionCacheContext("two"))
+	assert.Equal(2, client.myDetails.count)
+
+	now = now.Add(time.Minute)
+	_, _ = cache.CurrentUser(permissionCacheContext("one"))
+	assert.Equal(3, client.myDetails.count)
+
+	cache.Invalidate(permissionCacheContext("one"))
+	_, _ = cache.CurrentUser(permissionCacheContext("one"))
+	assert.Equal(4, client.myDetails.count)
+}
+
+func TestPermissionCacheCurrentUserError(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	client.myDetails.err = errors.New("oops")
+	cache := newPermissionCache(client, time.Minute)
+
+	_, err := cache.CurrentUser(permissionCacheContext("one"))
+	assert.Equal(client.myDetails.err, err)
+	_, _ = cache.CurrentUser(permissionCacheContext("one"))
+
+	assert.Equal(2, client.myDetails.count)
+}
+
+func TestErrorHandlerRecordsUserID(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	client.myDetails.data = sirius.MyDetails{ID: 123}
+	cache := newPermissionCache(client, time.Minute)
+
+	var buf bytes.Buffer
+	logger := logging.New(&buf, "opg-sirius-user-management")
+
+	wrap := errorHandler(logger, cache, &mockTemplate{}, "/prefix", "http://sirius")
+	handler := logger.Access(wrap(func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		return StatusError(http.StatusNotFound)
+	}))
+
+	r, _ := http.NewRequest("GET", "/path", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Contains(lines[len(lines)-1], `"type":"access"`)
+	assert.Contains(lines[len(lines)-1], `"user_id":123`)
+}
+
+func TestErrorHandlerOnlyLooksUpUserIDWhenLogging(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockPermissionCacheClient{}
+	client.myDetails.data = sirius.MyDetails{ID: 123}
+	cache := newPermissionCache(client, 0)
+
+	var buf bytes.Buffer
+	logger := logging.New(&buf, "opg-sirius-user-management")
+	logger.SetLevel(logging.LevelWarn)
+
+	wrap := errorHandler(logger, cache, &mockTemplate{}, "/prefix", "http://sirius")
+	handler := logger.Access(wrap(func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}))
+
+	r, _ := http.NewRequest("GET", "/path", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(2, client.myPermissions.count)
+	assert.Equal(0, client.myDetails.count)
+}
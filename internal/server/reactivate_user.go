@@ -0,0 +1,116 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+type ReactivateUserClient interface {
+	User(sirius.Context, int) (sirius.AuthUser, error)
+	EditUser(sirius.Context, sirius.AuthUser) error
+}
+
+type reactivateUserVars struct {
+	Path      string
+	XSRFToken string
+	User      sirius.AuthUser
+	Reason    string
+	Errors    sirius.ValidationErrors
+}
+
+func reactivateUser(client ReactivateUserClient, tmpl Template, audit AuditLogger, suspensions Suspensions) Handler {
+	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		if !perm.HasPermission("v1-users", http.MethodPut) {
+			return StatusError(http.StatusForbidden)
+		}
+
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/reactivate-user/"))
+		if err != nil {
+			return StatusError(http.StatusNotFound)
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			return StatusError(http.StatusMethodNotAllowed)
+		}
+
+		ctx := getContext(r)
+
+		user, err := client.User(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		vars := reactivateUserVars{
+			Path:      r.URL.Path,
+			XSRFToken: ctx.XSRFToken,
+			User:      user,
+		}
+
+		if r.Method == http.MethodPost {
+			if !user.Suspended {
+				return RedirectError(fmt.Sprintf("/users/%d", user.ID))
+			}
+
+			vars.Reason = strings.TrimSpace(r.PostFormValue("reason"))
+
+			if vars.Reason == "" {
+				vars.Errors = sirius.ValidationErrors{
+					"reason": {
+						"required": "Enter a reason for reactivating the user",
+					},
+				}
+
+				w.WriteHeader(http.StatusBadRequest)
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
+			user.Suspended = false
+			err := client.EditUser(ctx, user)
+
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "reactivate-user",
+				TargetType: "user",
+				TargetID:   id,
+				Reason:     vars.Reason,
+				Before:     vars.User,
+				After:      user,
+				Err:        err,
+			})
+
+			if _, ok := err.(sirius.ClientError); ok {
+				vars.Errors = sirius.ValidationErrors{
+					"": {
+						"": err.Error(),
+					},
+				}
+
+				w.WriteHeader(http.StatusBadRequest)
+			} else if err != nil {
+				return err
+			} else {
+				err := suspensions.Append(history.Suspension{
+					UserID:    user.ID,
+					UserName:  user.Firstname + " " + user.Surname,
+					UserEmail: user.Email,
+					Suspended: false,
+					Reason:    vars.Reason,
+					Timestamp: time.Now(),
+				})
+				if err != nil {
+					return err
+				}
+
+				return RedirectError(fmt.Sprintf("/users/%d", user.ID))
+			}
+		}
+
+		return tmpl.ExecuteTemplate(w, "page", vars)
+	}
+}
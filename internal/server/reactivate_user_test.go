@@ -0,0 +1,266 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReactivateUserClient struct {
+	user struct {
+		count   int
+		lastCtx sirius.Context
+		lastID  int
+		data    sirius.AuthUser
+		err     error
+	}
+
+	editUser struct {
+		count    int
+		lastCtx  sirius.Context
+		lastUser sirius.AuthUser
+		err      error
+	}
+}
+
+func (m *mockReactivateUserClient) User(ctx sirius.Context, id int) (sirius.AuthUser, error) {
+	m.user.count += 1
+	m.user.lastCtx = ctx
+	m.user.lastID = id
+
+	return m.user.data, m.user.err
+}
+
+func (m *mockReactivateUserClient) EditUser(ctx sirius.Context, user sirius.AuthUser) error {
+	m.editUser.count += 1
+	m.editUser.lastCtx = ctx
+	m.editUser.lastUser = user
+
+	return m.editUser.err
+}
+
+func (m *mockReactivateUserClient) requiredPermissions() sirius.PermissionSet {
+	return sirius.PermissionSet{"v1-users": sirius.PermissionGroup{Permissions: []string{"put"}}}
+}
+
+func TestGetReactivateUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockReactivateUserClient{}
+	client.user.data = sirius.AuthUser{Firstname: "test", Suspended: true}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/reactivate-user/123", nil)
+
+	err := reactivateUser(client, template, &mockAuditLogger{}, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(1, client.user.count)
+	assert.Equal(123, client.user.lastID)
+	assert.Equal(0, client.editUser.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal("page", template.lastName)
+	assert.Equal(reactivateUserVars{
+		Path: "/reactivate-user/123",
+		User: client.user.data,
+	}, template.lastVars)
+}
+
+func TestGetReactivateUserNoPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path", nil)
+
+	err := reactivateUser(nil, nil, nil, nil)(sirius.PermissionSet{}, w, r)
+	assert.Equal(StatusError(http.StatusForbidden), err)
+}
+
+func TestGetReactivateUserError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedError := errors.New("oops")
+
+	client := &mockReactivateUserClient{}
+	client.user.err = expectedError
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/reactivate-user/123", nil)
+
+	err := reactivateUser(client, &mockTemplate{}, &mockAuditLogger{}, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Equal(expectedError, err)
+}
+
+func TestPostReactivateUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockReactivateUserClient{}
+	client.user.data = sirius.AuthUser{ID: 123, Firstname: "Some", Surname: "User", Email: "user@opgtest.com", Suspended: true}
+	template := &mockTemplate{}
+	suspensions := &mockSuspensions{}
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/reactivate-user/123", strings.NewReader("reason=Back+from+leave"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := reactivateUser(client, template, audit, suspensions)(client.requiredPermissions(), w, r)
+	assert.Equal(RedirectError("/users/123"), err)
+
+	assert.Equal(1, client.editUser.count)
+	assert.Equal(sirius.AuthUser{
+		ID:        123,
+		Firstname: "Some",
+		Surname:   "User",
+		Email:     "user@opgtest.com",
+		Suspended: false,
+	}, client.editUser.lastUser)
+
+	assert.Equal(0, template.count)
+
+	assert.Equal(1, audit.count)
+	assert.Equal(logging.AuditEvent{
+		Action:     "reactivate-user",
+		TargetType: "user",
+		TargetID:   123,
+		Reason:     "Back from leave",
+		Before:     client.user.data,
+		After:      client.editUser.lastUser,
+	}, audit.lastEvent())
+
+	assert.Equal(1, suspensions.append.count)
+	suspension := suspensions.append.lastSuspension
+	assert.WithinDuration(time.Now(), suspension.Timestamp, time.Second)
+	suspension.Timestamp = time.Time{}
+	assert.Equal(history.Suspension{
+		UserID:    123,
+		UserName:  "Some User",
+		UserEmail: "user@opgtest.com",
+		Suspended: false,
+		Reason:    "Back from leave",
+	}, suspension)
+}
+
+func TestPostReactivateUserMissingReason(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockReactivateUserClient{}
+	client.user.data = sirius.AuthUser{Suspended: true}
+	template := &mockTemplate{}
+	suspensions := &mockSuspensions{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/reactivate-user/123", strings.NewReader("reason="))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := reactivateUser(client, template, &mockAuditLogger{}, suspensions)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(0, client.editUser.count)
+	assert.Equal(0, suspensions.append.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal(reactivateUserVars{
+		Path: "/reactivate-user/123",
+		User: client.user.data,
+		Errors: sirius.ValidationErrors{
+			"reason": {
+				"required": "Enter a reason for reactivating the user",
+			},
+		},
+	}, template.lastVars)
+}
+
+func TestPostReactivateUserClientError(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockReactivateUserClient{}
+	client.user.data = sirius.AuthUser{Suspended: true}
+	client.editUser.err = sirius.ClientError("problem")
+	template := &mockTemplate{}
+	suspensions := &mockSuspensions{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/reactivate-user/123", strings.NewReader("reason=Back"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := reactivateUser(client, template, &mockAuditLogger{}, suspensions)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(0, suspensions.append.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal(reactivateUserVars{
+		Path:   "/reactivate-user/123",
+		User:   client.user.data,
+		Reason: "Back",
+		Errors: sirius.ValidationErrors{
+			"": {
+				"": "problem",
+			},
+		},
+	}, template.lastVars)
+}
+
+func TestPostReactivateUserOtherError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("oops")
+	client := &mockReactivateUserClient{}
+	client.user.data = sirius.AuthUser{Suspended: true}
+	client.editUser.err = expectedErr
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/reactivate-user/123", strings.NewReader("reason=Back"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := reactivateUser(client, &mockTemplate{}, audit, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Equal(expectedErr, err)
+	assert.Equal(expectedErr, audit.lastEvent().Err)
+}
+
+func TestPostReactivateUserAlreadyActive(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockReactivateUserClient{}
+	client.user.data = sirius.AuthUser{ID: 123}
+	template := &mockTemplate{}
+	suspensions := &mockSuspensions{}
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/reactivate-user/123", strings.NewReader("reason=Back"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := reactivateUser(client, template, audit, suspensions)(client.requiredPermissions(), w, r)
+	assert.Equal(RedirectError("/users/123"), err)
+
+	assert.Equal(0, client.editUser.count)
+	assert.Equal(0, audit.count)
+	assert.Equal(0, suspensions.append.count)
+	assert.Equal(0, template.count)
+}
+
+func TestPutReactivateUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockReactivateUserClient{}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", "/reactivate-user/123", nil)
+
+	err := reactivateUser(nil, nil, nil, nil)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -18,11 +19,13 @@ type removeTeamMemberVars struct {
 	Path      string
 	XSRFToken string
 	Team      sirius.Team
+	Hash      string
 	Selected  map[int]string
+	Conflict  *teamConflict
 	Errors    sirius.ValidationErrors
 }
 
-func removeTeamMember(client RemoveTeamMemberClient, tmpl Template) Handler {
+func removeTeamMember(client RemoveTeamMemberClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-teams", http.MethodPut) {
 			return StatusError(http.StatusForbidden)
@@ -52,6 +55,7 @@ func removeTeamMember(client RemoveTeamMemberClient, tmpl Template) Handler {
 			Path:      r.URL.Path,
 			XSRFToken: ctx.XSRFToken,
 			Team:      team,
+			Hash:      r.PostFormValue("hash"),
 			Selected:  make(map[int]string),
 		}
 
@@ -78,8 +82,23 @@ func removeTeamMember(client RemoveTeamMemberClient, tmpl Template) Handler {
 
 			team.Members = members
 
+			if vars.Hash != teamHash(vars.Team) {
+				vars.Conflict = newTeamConflict(r, vars.Team, team)
+				w.WriteHeader(http.StatusConflict)
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
 			err = client.EditTeam(ctx, team)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "remove-team-member",
+				TargetType: "team",
+				TargetID:   id,
+				Before:     vars.Team,
+				After:      team,
+				Err:        err,
+			})
+
 			if _, ok := err.(sirius.ClientError); ok {
 				vars.Errors = sirius.ValidationErrors{
 					"_": {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -74,7 +75,7 @@ func TestPostRemoveTeamMember(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=12&selected[]=45"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := removeTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := removeTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -101,7 +102,7 @@ func TestPostRemoveTeamMemberNoPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", nil)
 
-	err := removeTeamMember(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := removeTeamMember(nil, nil, &mockAuditLogger{})(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -118,7 +119,7 @@ func TestPostRemoveTeamMemberBadPath(t *testing.T) {
 			client := &mockRemoveTeamMemberClient{}
 			r, _ := http.NewRequest("POST", path, strings.NewReader("selected[]=12&selected[]=45"))
 			r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-			err := editTeam(nil, nil)(client.requiredPermissions(), nil, r)
+			err := editTeam(nil, nil, &mockAuditLogger{})(client.requiredPermissions(), nil, r)
 
 			assert.Equal(StatusError(http.StatusNotFound), err)
 		})
@@ -138,7 +139,7 @@ func TestPostRemoveTeamMemberTeamError(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=12&selected[]=45"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := removeTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := removeTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedError, err)
 
 	assert.Equal(1, client.team.count)
@@ -160,7 +161,7 @@ func TestPostRemoveTeamMemberBadData(t *testing.T) {
 			r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader(data))
 			r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-			err := removeTeamMember(client, template)(client.requiredPermissions(), w, r)
+			err := removeTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 			assert.Equal(StatusError(http.StatusBadRequest), err)
 
 			assert.Equal(0, client.editTeam.count)
@@ -179,7 +180,7 @@ func TestPostRemoveTeamMemberIgnoresBadIds(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=19&selected[]=45"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := removeTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := removeTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(removeTeamMemberVars{
@@ -199,10 +200,10 @@ func TestConfirmPostRemoveTeamMember(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=12&selected[]=45&confirm=true"))
+	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=12&selected[]=45&confirm=true&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := removeTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := removeTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(RedirectError("/teams/123"), err)
 
 	assert.Equal(1, client.team.count)
@@ -225,10 +226,10 @@ func TestConfirmPostRemoveTeamMemberClientError(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=12&selected[]=45&confirm=true"))
+	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=12&selected[]=45&confirm=true&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := removeTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := removeTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.team.count)
@@ -237,6 +238,7 @@ func TestConfirmPostRemoveTeamMemberClientError(t *testing.T) {
 	assert.Equal(removeTeamMemberVars{
 		Path: "/teams/remove-member/123",
 		Team: client.team.data,
+		Hash: teamHash(client.team.data),
 		Selected: map[int]string{
 			12: "User 12",
 			45: "User 45",
@@ -249,6 +251,36 @@ func TestConfirmPostRemoveTeamMemberClientError(t *testing.T) {
 	}, template.lastVars)
 }
 
+func TestConfirmPostRemoveTeamMemberConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockRemoveTeamMemberClient{}
+	client.team.data = generateTeamWithIds(12, 16, 45, 50)
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=12&selected[]=45&confirm=true&hash="+teamHash(generateTeamWithIds(12, 16, 45))))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := removeTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusConflict, w.Result().StatusCode)
+	assert.Equal(0, client.editTeam.count)
+
+	assert.Equal(&teamConflict{
+		Hash: teamHash(client.team.data),
+		Fields: url.Values{
+			"selected[]": {"12", "45"},
+			"confirm":    {"true"},
+		},
+		Left: []sirius.TeamMember{
+			{ID: 12, DisplayName: "User 12"},
+			{ID: 45, DisplayName: "User 45"},
+		},
+	}, template.lastVars.(removeTeamMemberVars).Conflict)
+}
+
 func TestConfirmPostRemoveTeamMemberOtherError(t *testing.T) {
 	assert := assert.New(t)
 
@@ -260,10 +292,10 @@ func TestConfirmPostRemoveTeamMemberOtherError(t *testing.T) {
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
-	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=12&selected[]=45&confirm=true"))
+	r, _ := http.NewRequest("POST", "/teams/remove-member/123", strings.NewReader("selected[]=12&selected[]=45&confirm=true&hash="+teamHash(client.team.data)))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := removeTeamMember(client, template)(client.requiredPermissions(), w, r)
+	err := removeTeamMember(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedError, err)
 
 	assert.Equal(1, client.team.count)
@@ -277,6 +309,6 @@ func TestGetRemoveTeamMemberTeam(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/remove-member/123", nil)
 
-	err := removeTeamMember(nil, nil)(client.requiredPermissions(), w, r)
+	err := removeTeamMember(nil, nil, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
 }
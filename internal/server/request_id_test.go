@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	var id string
+	handler := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = requestid.FromContext(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path", nil)
+	r.Header.Set("X-Request-ID", "abc-123")
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal("abc-123", id)
+	assert.Equal("abc-123", w.Header().Get("X-Request-ID"))
+}
+
+func TestWithRequestIDGenerated(t *testing.T) {
+	for name, header := range map[string]string{
+		"missing": "",
+		"invalid": "abc 123",
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var id string
+			handler := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id = requestid.FromContext(r.Context())
+			}))
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/path", nil)
+			r.Header.Set("X-Request-ID", header)
+
+			handler.ServeHTTP(w, r)
+
+			assert.Len(id, 32)
+			assert.Equal(id, w.Header().Get("X-Request-ID"))
+		})
+	}
+}
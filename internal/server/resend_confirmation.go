@@ -2,7 +2,9 @@ package server
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -16,7 +18,7 @@ type resendConfirmationVars struct {
 	Email string
 }
 
-func resendConfirmation(client ResendConfirmationClient, tmpl Template) Handler {
+func resendConfirmation(client ResendConfirmationClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-users", http.MethodPut) {
 			return StatusError(http.StatusForbidden)
@@ -34,6 +36,15 @@ func resendConfirmation(client ResendConfirmationClient, tmpl Template) Handler
 			}
 
 			err := client.ResendConfirmation(getContext(r), vars.Email)
+
+			id, _ := strconv.Atoi(vars.ID)
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "resend-confirmation",
+				TargetType: "user",
+				TargetID:   id,
+				Err:        err,
+			})
+
 			if err != nil {
 				return err
 			}
@@ -37,7 +37,7 @@ func TestGetResendConfirmation(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := resendConfirmation(nil, nil)(client.requiredPermissions(), w, r)
+	err := resendConfirmation(nil, nil, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(RedirectError("/users"), err)
 }
 
@@ -47,7 +47,7 @@ func TestGetResendConfirmationNoPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := resendConfirmation(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := resendConfirmation(nil, nil, &mockAuditLogger{})(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -61,7 +61,7 @@ func TestPostResendConfirmation(t *testing.T) {
 	r, _ := http.NewRequest("POST", "/path", strings.NewReader("email=a&id=b"))
 	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	err := resendConfirmation(client, template)(client.requiredPermissions(), w, r)
+	err := resendConfirmation(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.count)
@@ -88,6 +88,6 @@ func TestPostResendConfirmationError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/path", nil)
 
-	err := resendConfirmation(client, nil)(client.requiredPermissions(), w, r)
+	err := resendConfirmation(client, nil, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedErr, err)
 }
@@ -0,0 +1,9 @@
+package server
+
+import "github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+
+// RolePolicy checks the roles given to a user, returning a message for each
+// rule that they break.
+type RolePolicy interface {
+	Check(organisation sirius.Organisation, roles []string) map[string]string
+}
@@ -1,22 +1,30 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Logger interface {
 	Request(*http.Request, error)
+	Audit(*http.Request, logging.AuditEvent)
 }
 
 type Client interface {
 	AddTeamClient
+	AddTeamMembersClient
 	AddUserClient
+	AuditClient
 	ChangePasswordClient
 	DeleteTeamClient
 	DeleteUserClient
@@ -24,95 +32,160 @@ type Client interface {
 	EditTeamClient
 	EditUserClient
 	ErrorHandlerClient
+	ExportUsersClient
+	FlushCacheClient
+	HealthCheckClient
+	ImportUsersClient
 	ListTeamsClient
 	ListUsersClient
+	MoveTeamMembersClient
 	MyDetailsClient
+	ReactivateUserClient
 	ResendConfirmationClient
+	SuspendUserClient
 	UnlockUserClient
 	ViewTeamClient
+	ViewUserClient
 }
 
 type Template interface {
 	ExecuteTemplate(io.Writer, string, interface{}) error
 }
 
-func New(logger Logger, client Client, templates map[string]*template.Template, prefix, siriusURL, siriusPublicURL, webDir string) http.Handler {
-	wrap := errorHandler(logger, client, templates["error.gotmpl"], prefix, siriusPublicURL)
+func New(logger Logger, client Client, teamHistory TeamHistory, suspensions Suspensions, rolePolicy RolePolicy, tmpls map[string]*template.Template, prefix, siriusURL, siriusPublicURL, webDir string, permissionsTTL time.Duration) (http.Handler, error) {
+	client = newPermissionCache(client, permissionsTTL)
+	templates := newTemplateRegistry(tmpls)
+
+	wrap := errorHandler(logger, client, templates.Get("error.gotmpl", errorVars{}), prefix, siriusPublicURL)
+	audit := actorAuditor{
+		logger: teamHistoryRecorder{logger: logger, history: teamHistory, now: time.Now},
+		client: client,
+	}
 
 	mux := http.NewServeMux()
-	mux.Handle("/", http.RedirectHandler(prefix+"/my-details", http.StatusFound))
-	mux.Handle("/health-check", healthCheck())
+	handle := func(route string, handler http.Handler) {
+		mux.Handle(route, instrumentRoute(route, handler))
+	}
+
+	handle("/", http.RedirectHandler(prefix+"/my-details", http.StatusFound))
+	handle("/health-check", healthCheck())
+	handle("/health-check/live", healthCheck())
+	handle("/metrics", promhttp.Handler())
+
+	handle("/users",
+		wrap(
+			listUsers(client, templates.Get("users.gotmpl", listUsersVars{}), suspensions)))
+
+	handle("/users/",
+		wrap(
+			viewUser(client, templates.Get("user.gotmpl", viewUserVars{}))))
+
+	handle("/users/export",
+		wrap(
+			exportUsers(client)))
+
+	handle("/users/import",
+		wrap(
+			importUsers(client, templates.Get("import-users.gotmpl", importUsersVars{}), audit, rolePolicy)))
+
+	handle("/teams",
+		wrap(
+			listTeams(client, templates.Get("teams.gotmpl", listTeamsVars{}))))
 
-	mux.Handle("/users",
+	handle("/teams/",
 		wrap(
-			listUsers(client, templates["users.gotmpl"])))
+			viewTeam(client, templates.Get("team.gotmpl", viewTeamVars{}), teamHistory)))
 
-	mux.Handle("/teams",
+	handle("/teams/add",
 		wrap(
-			listTeams(client, templates["teams.gotmpl"])))
+			addTeam(client, templates.Get("team-add.gotmpl", addTeamVars{}), audit)))
 
-	mux.Handle("/teams/",
+	handle("/teams/edit/",
 		wrap(
-			viewTeam(client, templates["team.gotmpl"])))
+			editTeam(client, templates.Get("team-edit.gotmpl", editTeamVars{}), audit)))
 
-	mux.Handle("/teams/add",
+	handle("/teams/delete/",
 		wrap(
-			addTeam(client, templates["team-add.gotmpl"])))
+			deleteTeam(client, templates.Get("team-delete.gotmpl", deleteTeamVars{}), audit)))
 
-	mux.Handle("/teams/edit/",
+	handle("/teams/add-member/",
 		wrap(
-			editTeam(client, templates["team-edit.gotmpl"])))
+			addTeamMember(client, templates.Get("team-add-member.gotmpl", addTeamMemberVars{}), audit)))
 
-	mux.Handle("/teams/delete/",
+	handle("/teams/add-members/",
 		wrap(
-			deleteTeam(client, templates["team-delete.gotmpl"])))
+			addTeamMembers(client, templates.Get("team-add-members.gotmpl", addTeamMembersVars{}), audit)))
 
-	mux.Handle("/teams/add-member/",
+	handle("/teams/move-members/",
 		wrap(
-			addTeamMember(client, templates["team-add-member.gotmpl"])))
+			moveTeamMembers(client, templates.Get("team-move-members.gotmpl", moveTeamMembersVars{}), audit)))
 
-	mux.Handle("/teams/remove-member/",
+	handle("/teams/remove-member/",
 		wrap(
-			removeTeamMember(client, templates["team-remove-member.gotmpl"])))
+			removeTeamMember(client, templates.Get("team-remove-member.gotmpl", removeTeamMemberVars{}), audit)))
 
-	mux.Handle("/my-details",
+	handle("/my-details",
 		wrap(
-			myDetails(client, templates["my-details.gotmpl"])))
+			myDetails(client, templates.Get("my-details.gotmpl", myDetailsVars{}))))
 
-	mux.Handle("/my-details/edit",
+	handle("/my-details/edit",
 		wrap(
-			editMyDetails(client, templates["edit-my-details.gotmpl"])))
+			editMyDetails(client, templates.Get("edit-my-details.gotmpl", editMyDetailsVars{}), audit)))
 
-	mux.Handle("/change-password",
+	handle("/change-password",
 		wrap(
-			changePassword(client, templates["change-password.gotmpl"])))
+			changePassword(client, templates.Get("change-password.gotmpl", changePasswordVars{}), audit)))
 
-	mux.Handle("/add-user",
+	handle("/add-user",
 		wrap(
-			addUser(client, templates["add-user.gotmpl"])))
+			addUser(client, templates.Get("add-user.gotmpl", addUserVars{}), audit, rolePolicy)))
 
-	mux.Handle("/edit-user/",
+	handle("/edit-user/",
 		wrap(
-			editUser(client, templates["edit-user.gotmpl"])))
+			editUser(client, templates.Get("edit-user.gotmpl", editUserVars{}), audit, rolePolicy)))
 
-	mux.Handle("/unlock-user/",
+	handle("/unlock-user/",
 		wrap(
-			unlockUser(client, templates["unlock-user.gotmpl"])))
+			unlockUser(client, templates.Get("unlock-user.gotmpl", unlockUserVars{}), audit)))
 
-	mux.Handle("/delete-user/",
+	handle("/suspend-user/",
 		wrap(
-			deleteUser(client, templates["delete-user.gotmpl"])))
+			suspendUser(client, templates.Get("suspend-user.gotmpl", suspendUserVars{}), audit, suspensions)))
 
-	mux.Handle("/resend-confirmation",
+	handle("/reactivate-user/",
 		wrap(
-			resendConfirmation(client, templates["resend-confirmation.gotmpl"])))
+			reactivateUser(client, templates.Get("reactivate-user.gotmpl", reactivateUserVars{}), audit, suspensions)))
+
+	handle("/delete-user/",
+		wrap(
+			deleteUser(client, templates.Get("delete-user.gotmpl", deleteUserVars{}), audit)))
+
+	handle("/cache/flush",
+		wrap(
+			flushCache(client, audit)))
+
+	handle("/resend-confirmation",
+		wrap(
+			resendConfirmation(client, templates.Get("resend-confirmation.gotmpl", resendConfirmationVars{}), audit)))
 
 	static := http.FileServer(http.Dir(webDir + "/static"))
-	mux.Handle("/assets/", static)
-	mux.Handle("/javascript/", static)
-	mux.Handle("/stylesheets/", static)
+	handle("/assets/", static)
+	handle("/javascript/", static)
+	handle("/stylesheets/", static)
+
+	// validated last so that every template used above is checked
+	if err := templates.Validate(); err != nil {
+		return nil, err
+	}
 
-	return http.StripPrefix(prefix, mux)
+	handle("/health-check/ready", readinessCheck(client, webDir))
+
+	var handler http.Handler = http.StripPrefix(prefix, mux)
+	if access, ok := logger.(accessLogger); ok {
+		handler = access.Access(handler)
+	}
+
+	return withRequestID(handler), nil
 }
 
 type RedirectError string
@@ -143,31 +216,63 @@ type errorVars struct {
 	SiriusURL string
 	Path      string
 
-	Code  int
-	Error string
+	Code      int
+	Error     string
+	RequestID string
 }
 
 type ErrorHandlerClient interface {
 	MyPermissions(sirius.Context) (sirius.PermissionSet, error)
 }
 
+type accessLogger interface {
+	Access(http.Handler) http.Handler
+}
+
+type permissionInvalidator interface {
+	Invalidate(sirius.Context)
+}
+
 func errorHandler(logger Logger, client ErrorHandlerClient, tmplError Template, prefix, siriusURL string) func(next Handler) http.Handler {
 	return func(next Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wantsJSON(r) {
+				w = newJSONResponseWriter(w)
+			}
+
 			myPermissions, err := client.MyPermissions(getContext(r))
 
 			if err == nil {
-				err = next(myPermissions, w, r)
+				actorReq := withRequestActor(r)
+
+				if client, ok := client.(currentUserClient); ok {
+					logging.SetUserLookup(r.Context(), func() int {
+						myDetails, _ := currentUser(actorReq, client.CurrentUser)
+						return myDetails.ID
+					})
+				}
+
+				err = next(myPermissions, w, actorReq)
 			}
 
 			if err != nil {
-				if err == sirius.ErrUnauthorized {
+				if invalidator, ok := client.(permissionInvalidator); ok {
+					if status, ok := err.(StatusError); err == sirius.ErrUnauthorized || (ok && status.Code() == http.StatusForbidden) {
+						invalidator.Invalidate(getContext(r))
+					}
+				}
+
+				if err == sirius.ErrUnauthorized && !wantsJSON(r) {
 					http.Redirect(w, r, siriusURL+"/auth", http.StatusFound)
 					return
 				}
 
 				if redirect, ok := err.(RedirectError); ok {
-					http.Redirect(w, r, prefix+redirect.To(), http.StatusFound)
+					if wantsJSON(r) {
+						writeJSONRedirect(w, prefix+redirect.To())
+					} else {
+						http.Redirect(w, r, prefix+redirect.To(), http.StatusFound)
+					}
 					return
 				}
 
@@ -180,12 +285,22 @@ func errorHandler(logger Logger, client ErrorHandlerClient, tmplError Template,
 					}
 				}
 
+				if err == sirius.ErrUnauthorized {
+					code = http.StatusUnauthorized
+				}
+
+				if errors.Is(err, sirius.ErrUnavailable) {
+					code = http.StatusServiceUnavailable
+					err = sirius.ErrUnavailable
+				}
+
 				w.WriteHeader(code)
 				err = tmplError.ExecuteTemplate(w, "page", errorVars{
 					SiriusURL: siriusURL,
 					Path:      "",
 					Code:      code,
 					Error:     err.Error(),
+					RequestID: requestid.FromContext(r.Context()),
 				})
 
 				if err != nil {
@@ -6,9 +6,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,6 +28,28 @@ func (m *mockLogger) Request(r *http.Request, err error) {
 	m.lastError = err
 }
 
+func (m *mockLogger) Audit(r *http.Request, event logging.AuditEvent) {}
+
+type mockAuditLogger struct {
+	count       int
+	lastRequest *http.Request
+	events      []logging.AuditEvent
+}
+
+func (m *mockAuditLogger) Audit(r *http.Request, event logging.AuditEvent) {
+	m.count += 1
+	m.lastRequest = r
+	m.events = append(m.events, event)
+}
+
+func (m *mockAuditLogger) lastEvent() logging.AuditEvent {
+	if len(m.events) == 0 {
+		return logging.AuditEvent{}
+	}
+
+	return m.events[len(m.events)-1]
+}
+
 type mockTemplate struct {
 	count    int
 	lastName string
@@ -51,7 +76,9 @@ func (m *mockErrorHandlerClient) MyPermissions(ctx sirius.Context) (sirius.Permi
 }
 
 func TestNew(t *testing.T) {
-	assert.Implements(t, (*http.Handler)(nil), New(nil, nil, nil, "", "", "", ""))
+	handler, err := New(nil, nil, nil, nil, nil, nil, "", "", "", "", 0)
+	assert.Nil(t, handler)
+	assert.Contains(t, err.Error(), "template users.gotmpl: not found")
 }
 
 func TestErrorHandler(t *testing.T) {
@@ -102,6 +129,57 @@ func TestErrorHandlerUnauthorized(t *testing.T) {
 	assert.Equal(0, tmplError.count)
 }
 
+func TestErrorHandlerJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockErrorHandlerClient{}
+	tmpl := &mockTemplate{}
+
+	wrap := errorHandler(nil, client, nil, "/prefix", "http://sirius")
+	handler := wrap(func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		return jsonTemplate{tmpl}.ExecuteTemplate(w, "page", listTeamsVars{Path: "/teams"})
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path", nil)
+	r.Header.Set("Accept", "application/json")
+
+	handler.ServeHTTP(w, r)
+
+	resp := w.Result()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/json", resp.Header.Get("Content-Type"))
+	assert.Contains(w.Body.String(), `"Path":"/teams"`)
+
+	assert.Equal(0, tmpl.count)
+}
+
+func TestErrorHandlerJSONUnauthorized(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := &mockLogger{}
+	client := &mockErrorHandlerClient{}
+	tmplError := &mockTemplate{}
+
+	wrap := errorHandler(logger, client, jsonTemplate{tmplError}, "/prefix", "http://sirius")
+	handler := wrap(func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		return sirius.ErrUnauthorized
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path", nil)
+	r.Header.Set("Accept", "application/json")
+
+	handler.ServeHTTP(w, r)
+
+	resp := w.Result()
+	assert.Equal(http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal("application/json", resp.Header.Get("Content-Type"))
+	assert.JSONEq(`{"SiriusURL":"http://sirius","Path":"","Code":401,"Error":"unauthorized","RequestID":""}`, w.Body.String())
+
+	assert.Equal(0, tmplError.count)
+}
+
 func TestErrorHandlerMyPermissionsError(t *testing.T) {
 	assert := assert.New(t)
 
@@ -156,6 +234,32 @@ func TestErrorHandlerRedirect(t *testing.T) {
 	assert.Equal(0, tmplError.count)
 }
 
+func TestErrorHandlerRedirectJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockErrorHandlerClient{}
+	tmplError := &mockTemplate{}
+
+	wrap := errorHandler(nil, client, tmplError, "/prefix", "http://sirius")
+	handler := wrap(func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		return RedirectError("/teams/2")
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/path", nil)
+	r.Header.Set("Accept", "application/json")
+
+	handler.ServeHTTP(w, r)
+
+	resp := w.Result()
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal("application/json", resp.Header.Get("Content-Type"))
+	assert.Equal("/prefix/teams/2", resp.Header.Get("Location"))
+	assert.JSONEq(`{"location":"/prefix/teams/2"}`, w.Body.String())
+
+	assert.Equal(0, tmplError.count)
+}
+
 func TestErrorHandlerStatus(t *testing.T) {
 	assert := assert.New(t)
 
@@ -184,6 +288,28 @@ func TestErrorHandlerStatus(t *testing.T) {
 	assert.Equal(StatusError(http.StatusTeapot), logger.lastError)
 }
 
+func TestErrorHandlerRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := &mockLogger{}
+	client := &mockErrorHandlerClient{}
+	tmplError := &mockTemplate{}
+
+	wrap := errorHandler(logger, client, tmplError, "/prefix", "http://sirius")
+	handler := withRequestID(wrap(func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		return errors.New("err")
+	}))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path", nil)
+	r.Header.Set("X-Request-ID", "abc-123")
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(errorVars{SiriusURL: "http://sirius", Code: http.StatusInternalServerError, Error: "err", RequestID: "abc-123"}, tmplError.lastVars)
+	assert.Equal("abc-123", requestid.FromContext(logger.lastRequest.Context()))
+}
+
 func TestErrorHandlerStatusKnown(t *testing.T) {
 	for name, code := range map[string]int{
 		"Forbidden": http.StatusForbidden,
@@ -270,3 +396,32 @@ func TestGetContextForPostRequest(t *testing.T) {
 	assert.Equal(r.Cookies(), ctx.Cookies)
 	assert.Equal("the-real-one", ctx.XSRFToken)
 }
+
+func TestErrorHandlerSiriusUnavailable(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedError := &url.Error{Op: "Get", URL: "http://sirius/api/v1/permissions", Err: sirius.ErrUnavailable}
+
+	logger := &mockLogger{}
+	client := &mockErrorHandlerClient{}
+	tmplError := &mockTemplate{}
+
+	wrap := errorHandler(logger, client, tmplError, "/prefix", "http://sirius")
+	handler := wrap(func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		return expectedError
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path", nil)
+
+	handler.ServeHTTP(w, r)
+
+	resp := w.Result()
+	assert.Equal(http.StatusServiceUnavailable, resp.StatusCode)
+
+	assert.Equal(1, tmplError.count)
+	assert.Equal(errorVars{SiriusURL: "http://sirius", Code: http.StatusServiceUnavailable, Error: "Sirius is unavailable"}, tmplError.lastVars)
+
+	assert.Equal(1, logger.count)
+	assert.Equal(expectedError, logger.lastError)
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+type SuspendUserClient interface {
+	User(sirius.Context, int) (sirius.AuthUser, error)
+	EditUser(sirius.Context, sirius.AuthUser) error
+}
+
+type Suspensions interface {
+	Append(history.Suspension) error
+	ReviewDue(time.Time) ([]history.Suspension, error)
+}
+
+type suspendUserVars struct {
+	Path        string
+	XSRFToken   string
+	User        sirius.AuthUser
+	Reason      string
+	ReviewDay   string
+	ReviewMonth string
+	ReviewYear  string
+	Errors      sirius.ValidationErrors
+}
+
+func suspendUser(client SuspendUserClient, tmpl Template, audit AuditLogger, suspensions Suspensions) Handler {
+	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
+		if !perm.HasPermission("v1-users", http.MethodPut) {
+			return StatusError(http.StatusForbidden)
+		}
+
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/suspend-user/"))
+		if err != nil {
+			return StatusError(http.StatusNotFound)
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodPost {
+			return StatusError(http.StatusMethodNotAllowed)
+		}
+
+		ctx := getContext(r)
+
+		user, err := client.User(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		vars := suspendUserVars{
+			Path:      r.URL.Path,
+			XSRFToken: ctx.XSRFToken,
+			User:      user,
+		}
+
+		if r.Method == http.MethodPost {
+			if user.Suspended {
+				return RedirectError(fmt.Sprintf("/users/%d", user.ID))
+			}
+
+			vars.Reason = strings.TrimSpace(r.PostFormValue("reason"))
+			vars.ReviewDay = strings.TrimSpace(r.PostFormValue("review-day"))
+			vars.ReviewMonth = strings.TrimSpace(r.PostFormValue("review-month"))
+			vars.ReviewYear = strings.TrimSpace(r.PostFormValue("review-year"))
+
+			vars.Errors = sirius.ValidationErrors{}
+
+			if vars.Reason == "" {
+				vars.Errors["reason"] = map[string]string{"required": "Enter a reason for suspending the user"}
+			}
+
+			reviewDate, ok := parseReviewDate(vars.ReviewDay, vars.ReviewMonth, vars.ReviewYear)
+			if !ok {
+				vars.Errors["review-date"] = map[string]string{"invalid": "Review date must be a real date"}
+			} else if reviewDate != nil && !reviewDate.After(time.Now()) {
+				vars.Errors["review-date"] = map[string]string{"past": "Review date must be in the future"}
+			}
+
+			if len(vars.Errors) > 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return tmpl.ExecuteTemplate(w, "page", vars)
+			}
+
+			vars.Errors = nil
+
+			user.Suspended = true
+			err := client.EditUser(ctx, user)
+
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "suspend-user",
+				TargetType: "user",
+				TargetID:   id,
+				Reason:     vars.Reason,
+				Before:     vars.User,
+				After:      user,
+				Err:        err,
+			})
+
+			if _, ok := err.(sirius.ClientError); ok {
+				vars.Errors = sirius.ValidationErrors{
+					"": {
+						"": err.Error(),
+					},
+				}
+
+				w.WriteHeader(http.StatusBadRequest)
+			} else if err != nil {
+				return err
+			} else {
+				err := suspensions.Append(history.Suspension{
+					UserID:     user.ID,
+					UserName:   user.Firstname + " " + user.Surname,
+					UserEmail:  user.Email,
+					Suspended:  true,
+					Reason:     vars.Reason,
+					ReviewDate: reviewDate,
+					Timestamp:  time.Now(),
+				})
+				if err != nil {
+					return err
+				}
+
+				return RedirectError(fmt.Sprintf("/users/%d", user.ID))
+			}
+		}
+
+		return tmpl.ExecuteTemplate(w, "page", vars)
+	}
+}
+
+// parseReviewDate reads the parts of a date input, where leaving every part
+// empty means no date was given.
+func parseReviewDate(day, month, year string) (*time.Time, bool) {
+	if day == "" && month == "" && year == "" {
+		return nil, true
+	}
+
+	d, err := strconv.Atoi(day)
+	if err != nil {
+		return nil, false
+	}
+
+	m, err := strconv.Atoi(month)
+	if err != nil {
+		return nil, false
+	}
+
+	y, err := strconv.Atoi(year)
+	if err != nil || y < 1000 {
+		return nil, false
+	}
+
+	date := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if date.Day() != d || int(date.Month()) != m {
+		return nil, false
+	}
+
+	return &date, true
+}
@@ -0,0 +1,362 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockSuspensions struct {
+	append struct {
+		count          int
+		lastSuspension history.Suspension
+		err            error
+	}
+
+	reviewDue struct {
+		count   int
+		lastNow time.Time
+		data    []history.Suspension
+		err     error
+	}
+}
+
+func (m *mockSuspensions) Append(suspension history.Suspension) error {
+	m.append.count += 1
+	m.append.lastSuspension = suspension
+
+	return m.append.err
+}
+
+func (m *mockSuspensions) ReviewDue(now time.Time) ([]history.Suspension, error) {
+	m.reviewDue.count += 1
+	m.reviewDue.lastNow = now
+
+	return m.reviewDue.data, m.reviewDue.err
+}
+
+type mockSuspendUserClient struct {
+	user struct {
+		count   int
+		lastCtx sirius.Context
+		lastID  int
+		data    sirius.AuthUser
+		err     error
+	}
+
+	editUser struct {
+		count    int
+		lastCtx  sirius.Context
+		lastUser sirius.AuthUser
+		err      error
+	}
+}
+
+func (m *mockSuspendUserClient) User(ctx sirius.Context, id int) (sirius.AuthUser, error) {
+	m.user.count += 1
+	m.user.lastCtx = ctx
+	m.user.lastID = id
+
+	return m.user.data, m.user.err
+}
+
+func (m *mockSuspendUserClient) EditUser(ctx sirius.Context, user sirius.AuthUser) error {
+	m.editUser.count += 1
+	m.editUser.lastCtx = ctx
+	m.editUser.lastUser = user
+
+	return m.editUser.err
+}
+
+func (m *mockSuspendUserClient) requiredPermissions() sirius.PermissionSet {
+	return sirius.PermissionSet{"v1-users": sirius.PermissionGroup{Permissions: []string{"put"}}}
+}
+
+func TestGetSuspendUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockSuspendUserClient{}
+	client.user.data = sirius.AuthUser{Firstname: "test"}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/suspend-user/123", nil)
+
+	err := suspendUser(client, template, &mockAuditLogger{}, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(1, client.user.count)
+	assert.Equal(123, client.user.lastID)
+	assert.Equal(0, client.editUser.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal("page", template.lastName)
+	assert.Equal(suspendUserVars{
+		Path: "/suspend-user/123",
+		User: client.user.data,
+	}, template.lastVars)
+}
+
+func TestGetSuspendUserNoPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/path", nil)
+
+	err := suspendUser(nil, nil, nil, nil)(sirius.PermissionSet{}, w, r)
+	assert.Equal(StatusError(http.StatusForbidden), err)
+}
+
+func TestGetSuspendUserBadPath(t *testing.T) {
+	for name, path := range map[string]string{
+		"empty":       "/suspend-user/",
+		"non-numeric": "/suspend-user/hello",
+		"suffixed":    "/suspend-user/123/no",
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockSuspendUserClient{}
+			template := &mockTemplate{}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", path, nil)
+
+			err := suspendUser(client, template, &mockAuditLogger{}, &mockSuspensions{})(client.requiredPermissions(), w, r)
+			assert.Equal(StatusError(http.StatusNotFound), err)
+
+			assert.Equal(0, client.user.count)
+			assert.Equal(0, template.count)
+		})
+	}
+}
+
+func TestPostSuspendUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockSuspendUserClient{}
+	client.user.data = sirius.AuthUser{ID: 123, Firstname: "Some", Surname: "User", Email: "user@opgtest.com"}
+	template := &mockTemplate{}
+	suspensions := &mockSuspensions{}
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/suspend-user/123", strings.NewReader("reason=+On+leave+&review-day=2&review-month=3&review-year=2100"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := suspendUser(client, template, audit, suspensions)(client.requiredPermissions(), w, r)
+	assert.Equal(RedirectError("/users/123"), err)
+
+	assert.Equal(1, client.editUser.count)
+	assert.Equal(getContext(r), client.editUser.lastCtx)
+	assert.Equal(sirius.AuthUser{
+		ID:        123,
+		Firstname: "Some",
+		Surname:   "User",
+		Email:     "user@opgtest.com",
+		Suspended: true,
+	}, client.editUser.lastUser)
+
+	assert.Equal(0, template.count)
+
+	assert.Equal(1, audit.count)
+	assert.Equal(logging.AuditEvent{
+		Action:     "suspend-user",
+		TargetType: "user",
+		TargetID:   123,
+		Reason:     "On leave",
+		Before:     client.user.data,
+		After:      client.editUser.lastUser,
+	}, audit.lastEvent())
+
+	reviewDate := time.Date(2100, time.March, 2, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(1, suspensions.append.count)
+	suspension := suspensions.append.lastSuspension
+	assert.WithinDuration(time.Now(), suspension.Timestamp, time.Second)
+	suspension.Timestamp = time.Time{}
+	assert.Equal(history.Suspension{
+		UserID:     123,
+		UserName:   "Some User",
+		UserEmail:  "user@opgtest.com",
+		Suspended:  true,
+		Reason:     "On leave",
+		ReviewDate: &reviewDate,
+	}, suspension)
+}
+
+func TestPostSuspendUserWithoutReviewDate(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockSuspendUserClient{}
+	client.user.data = sirius.AuthUser{ID: 123}
+	suspensions := &mockSuspensions{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/suspend-user/123", strings.NewReader("reason=Left"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := suspendUser(client, &mockTemplate{}, &mockAuditLogger{}, suspensions)(client.requiredPermissions(), w, r)
+	assert.Equal(RedirectError("/users/123"), err)
+
+	assert.Equal(1, suspensions.append.count)
+	assert.Nil(suspensions.append.lastSuspension.ReviewDate)
+}
+
+func TestPostSuspendUserValidationErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		form   string
+		errors sirius.ValidationErrors
+	}{
+		"missing reason": {
+			form: "reason=+",
+			errors: sirius.ValidationErrors{
+				"reason": {"required": "Enter a reason for suspending the user"},
+			},
+		},
+		"incomplete date": {
+			form: "reason=Leave&review-day=1&review-year=2100",
+			errors: sirius.ValidationErrors{
+				"review-date": {"invalid": "Review date must be a real date"},
+			},
+		},
+		"impossible date": {
+			form: "reason=Leave&review-day=31&review-month=2&review-year=2100",
+			errors: sirius.ValidationErrors{
+				"review-date": {"invalid": "Review date must be a real date"},
+			},
+		},
+		"past date": {
+			form: "reason=Leave&review-day=1&review-month=2&review-year=2000",
+			errors: sirius.ValidationErrors{
+				"review-date": {"past": "Review date must be in the future"},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockSuspendUserClient{}
+			template := &mockTemplate{}
+			suspensions := &mockSuspensions{}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/suspend-user/123", strings.NewReader(tc.form))
+			r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+			err := suspendUser(client, template, &mockAuditLogger{}, suspensions)(client.requiredPermissions(), w, r)
+			assert.Nil(err)
+
+			assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+			assert.Equal(0, client.editUser.count)
+			assert.Equal(0, suspensions.append.count)
+
+			assert.Equal(1, template.count)
+			assert.Equal(tc.errors, template.lastVars.(suspendUserVars).Errors)
+		})
+	}
+}
+
+func TestPostSuspendUserAlreadySuspended(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockSuspendUserClient{}
+	client.user.data = sirius.AuthUser{ID: 123, Suspended: true}
+	template := &mockTemplate{}
+	suspensions := &mockSuspensions{}
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/suspend-user/123", strings.NewReader("reason=On+leave&review-day=2&review-month=3&review-year=2100"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := suspendUser(client, template, audit, suspensions)(client.requiredPermissions(), w, r)
+	assert.Equal(RedirectError("/users/123"), err)
+
+	assert.Equal(0, client.editUser.count)
+	assert.Equal(0, audit.count)
+	assert.Equal(0, suspensions.append.count)
+	assert.Equal(0, template.count)
+}
+
+func TestPostSuspendUserClientError(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockSuspendUserClient{}
+	client.editUser.err = sirius.ClientError("problem")
+	template := &mockTemplate{}
+	suspensions := &mockSuspensions{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/suspend-user/123", strings.NewReader("reason=Leave"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := suspendUser(client, template, &mockAuditLogger{}, suspensions)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(http.StatusBadRequest, w.Result().StatusCode)
+	assert.Equal(0, suspensions.append.count)
+
+	assert.Equal(1, template.count)
+	assert.Equal(suspendUserVars{
+		Path:   "/suspend-user/123",
+		Reason: "Leave",
+		Errors: sirius.ValidationErrors{
+			"": {
+				"": "problem",
+			},
+		},
+	}, template.lastVars)
+}
+
+func TestPostSuspendUserOtherError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("oops")
+	client := &mockSuspendUserClient{}
+	client.editUser.err = expectedErr
+	audit := &mockAuditLogger{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/suspend-user/123", strings.NewReader("reason=Leave"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := suspendUser(client, &mockTemplate{}, audit, &mockSuspensions{})(client.requiredPermissions(), w, r)
+	assert.Equal(expectedErr, err)
+	assert.Equal(expectedErr, audit.lastEvent().Err)
+}
+
+func TestPostSuspendUserSuspensionsError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedErr := errors.New("oops")
+	client := &mockSuspendUserClient{}
+	suspensions := &mockSuspensions{}
+	suspensions.append.err = expectedErr
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/suspend-user/123", strings.NewReader("reason=Leave"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	err := suspendUser(client, &mockTemplate{}, &mockAuditLogger{}, suspensions)(client.requiredPermissions(), w, r)
+	assert.Equal(expectedErr, err)
+}
+
+func TestPutSuspendUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockSuspendUserClient{}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("PUT", "/suspend-user/123", nil)
+
+	err := suspendUser(nil, nil, nil, nil)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
+}
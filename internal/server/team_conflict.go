@@ -0,0 +1,105 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+type teamConflict struct {
+	Hash    string
+	Fields  url.Values
+	Joined  []sirius.TeamMember
+	Left    []sirius.TeamMember
+	Details []teamDetailChange
+}
+
+type teamDetailChange struct {
+	Name   string
+	Before string
+	After  string
+}
+
+// teamHash identifies the state of a team that can be changed through
+// EditTeam. It is given out with each form so that a change can be rejected if
+// someone else has changed the team since the form was shown.
+func teamHash(team sirius.Team) string {
+	memberIDs := make([]int, len(team.Members))
+	for i, member := range team.Members {
+		memberIDs[i] = member.ID
+	}
+	sort.Ints(memberIDs)
+
+	data, _ := json.Marshal(struct {
+		ID          int
+		DisplayName string
+		Type        string
+		Email       string
+		PhoneNumber string
+		MemberIDs   []int
+	}{
+		ID:          team.ID,
+		DisplayName: team.DisplayName,
+		Type:        team.Type,
+		Email:       team.Email,
+		PhoneNumber: team.PhoneNumber,
+		MemberIDs:   memberIDs,
+	})
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// newTeamConflict describes what the change would do if it were applied to the
+// current state of the team, and keeps the submitted fields so that it can be
+// made again.
+func newTeamConflict(r *http.Request, current, reapplied sirius.Team) *teamConflict {
+	conflict := &teamConflict{
+		Hash:   teamHash(current),
+		Fields: url.Values{},
+	}
+
+	for name, values := range r.PostForm {
+		if name != "xsrfToken" && name != "hash" {
+			conflict.Fields[name] = values
+		}
+	}
+
+	isMember := map[int]bool{}
+	for _, member := range current.Members {
+		isMember[member.ID] = true
+	}
+
+	willBeMember := map[int]bool{}
+	for _, member := range reapplied.Members {
+		willBeMember[member.ID] = true
+
+		if !isMember[member.ID] {
+			conflict.Joined = append(conflict.Joined, member)
+		}
+	}
+
+	for _, member := range current.Members {
+		if !willBeMember[member.ID] {
+			conflict.Left = append(conflict.Left, member)
+		}
+	}
+
+	for _, detail := range []teamDetailChange{
+		{Name: "Name", Before: current.DisplayName, After: reapplied.DisplayName},
+		{Name: "Type", Before: current.Type, After: reapplied.Type},
+		{Name: "Phone number", Before: current.PhoneNumber, After: reapplied.PhoneNumber},
+		{Name: "Email", Before: current.Email, After: reapplied.Email},
+	} {
+		if detail.Before != detail.After {
+			conflict.Details = append(conflict.Details, detail)
+		}
+	}
+
+	return conflict
+}
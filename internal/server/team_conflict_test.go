@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamHash(t *testing.T) {
+	assert := assert.New(t)
+
+	team := sirius.Team{
+		ID:          5,
+		DisplayName: "Allocations",
+		Members:     []sirius.TeamMember{{ID: 1}, {ID: 2}},
+	}
+
+	reordered := team
+	reordered.Members = []sirius.TeamMember{{ID: 2, DisplayName: "Bob"}, {ID: 1}}
+	assert.Equal(teamHash(team), teamHash(reordered))
+
+	relabelled := team
+	relabelled.TypeLabel = "Allocations"
+	assert.Equal(teamHash(team), teamHash(relabelled))
+
+	for name, change := range map[string]func(*sirius.Team){
+		"ID":          func(t *sirius.Team) { t.ID = 6 },
+		"DisplayName": func(t *sirius.Team) { t.DisplayName = "Other" },
+		"Type":        func(t *sirius.Team) { t.Type = "FINANCE" },
+		"Email":       func(t *sirius.Team) { t.Email = "team@opgtest.com" },
+		"PhoneNumber": func(t *sirius.Team) { t.PhoneNumber = "0123" },
+		"Members":     func(t *sirius.Team) { t.Members = t.Members[:1] },
+	} {
+		changed := team
+		change(&changed)
+
+		assert.NotEqual(teamHash(team), teamHash(changed), name)
+	}
+}
+
+func TestNewTeamConflict(t *testing.T) {
+	assert := assert.New(t)
+
+	current := sirius.Team{
+		ID:          5,
+		DisplayName: "Allocations",
+		Members:     []sirius.TeamMember{{ID: 1, DisplayName: "Anne"}, {ID: 2, DisplayName: "Bob"}},
+	}
+
+	reapplied := current
+	reapplied.DisplayName = "Lay allocations"
+	reapplied.Members = []sirius.TeamMember{{ID: 2, DisplayName: "Bob"}, {ID: 3, DisplayName: "Carl"}}
+
+	r, _ := http.NewRequest("POST", "/teams/edit/5", strings.NewReader("xsrfToken=abcde&hash=abc&name=Lay+allocations"))
+	r.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	_ = r.ParseForm()
+
+	assert.Equal(&teamConflict{
+		Hash:    teamHash(current),
+		Fields:  url.Values{"name": {"Lay allocations"}},
+		Joined:  []sirius.TeamMember{{ID: 3, DisplayName: "Carl"}},
+		Left:    []sirius.TeamMember{{ID: 1, DisplayName: "Anne"}},
+		Details: []teamDetailChange{{Name: "Name", Before: "Allocations", After: "Lay allocations"}},
+	}, newTeamConflict(r, current, reapplied))
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+)
+
+type TeamHistory interface {
+	Append(...history.Entry) error
+	Team(int) ([]history.Entry, error)
+}
+
+// teamHistoryRecorder passes events on to the logger, then records the users
+// that joined or left a team for each successful change to its members.
+type teamHistoryRecorder struct {
+	logger  Logger
+	history TeamHistory
+	now     func() time.Time
+}
+
+func (h teamHistoryRecorder) Audit(r *http.Request, event logging.AuditEvent) {
+	h.logger.Audit(r, event)
+
+	if event.Err != nil || event.TargetType != "team" {
+		return
+	}
+
+	before, ok := event.Before.(sirius.Team)
+	if !ok {
+		return
+	}
+
+	after, ok := event.After.(sirius.Team)
+	if !ok {
+		return
+	}
+
+	entries := teamMembershipChanges(before, after)
+	if len(entries) == 0 {
+		return
+	}
+
+	now := h.now().UTC()
+	for i := range entries {
+		entries[i].ActorID = event.ActorID
+		entries[i].ActorName = event.ActorName
+		entries[i].Timestamp = now
+	}
+
+	if err := h.history.Append(entries...); err != nil {
+		h.logger.Request(r, err)
+	}
+}
+
+func teamMembershipChanges(before, after sirius.Team) []history.Entry {
+	var entries []history.Entry
+
+	wasMember := map[int]bool{}
+	for _, member := range before.Members {
+		wasMember[member.ID] = true
+	}
+
+	isMember := map[int]bool{}
+	for _, member := range after.Members {
+		isMember[member.ID] = true
+
+		if !wasMember[member.ID] {
+			entries = append(entries, teamMembershipEntry(after.ID, member, history.Joined))
+		}
+	}
+
+	for _, member := range before.Members {
+		if !isMember[member.ID] {
+			entries = append(entries, teamMembershipEntry(before.ID, member, history.Left))
+		}
+	}
+
+	return entries
+}
+
+func teamMembershipEntry(teamID int, member sirius.TeamMember, direction history.Direction) history.Entry {
+	name := member.DisplayName
+	if name == "" {
+		name = member.Email
+	}
+
+	return history.Entry{
+		TeamID:    teamID,
+		UserID:    member.ID,
+		UserName:  name,
+		Direction: direction,
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockTeamHistory struct {
+	append struct {
+		count       int
+		lastEntries []history.Entry
+		err         error
+	}
+	team struct {
+		count  int
+		lastID int
+		data   []history.Entry
+		err    error
+	}
+}
+
+func (m *mockTeamHistory) Append(entries ...history.Entry) error {
+	m.append.count += 1
+	m.append.lastEntries = entries
+
+	return m.append.err
+}
+
+func (m *mockTeamHistory) Team(id int) ([]history.Entry, error) {
+	m.team.count += 1
+	m.team.lastID = id
+
+	return m.team.data, m.team.err
+}
+
+type mockTeamHistoryLogger struct {
+	mockAuditLogger
+	mockLogger
+}
+
+func (m *mockTeamHistoryLogger) Audit(r *http.Request, event logging.AuditEvent) {
+	m.mockAuditLogger.Audit(r, event)
+}
+
+func TestTeamHistoryRecorder(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2020, time.October, 1, 9, 0, 0, 0, time.UTC)
+
+	logger := &mockTeamHistoryLogger{}
+	teamHistory := &mockTeamHistory{}
+	recorder := teamHistoryRecorder{logger: logger, history: teamHistory, now: func() time.Time { return now }}
+
+	event := logging.AuditEvent{
+		ActorID:    47,
+		ActorName:  "Anne Admin",
+		Action:     "edit-team",
+		TargetType: "team",
+		TargetID:   1,
+		Before: sirius.Team{
+			ID:      1,
+			Members: []sirius.TeamMember{{ID: 10, DisplayName: "Bob"}, {ID: 11, DisplayName: "Carl"}},
+		},
+		After: sirius.Team{
+			ID:      1,
+			Members: []sirius.TeamMember{{ID: 11, DisplayName: "Carl"}, {ID: 12, Email: "dee@opgtest.com"}},
+		},
+	}
+
+	r, _ := http.NewRequest("POST", "/teams/edit/1", nil)
+	recorder.Audit(r, event)
+
+	assert.Equal(1, logger.mockAuditLogger.count)
+	assert.Equal(event, logger.lastEvent())
+
+	assert.Equal(1, teamHistory.append.count)
+	assert.Equal([]history.Entry{
+		{TeamID: 1, UserID: 12, UserName: "dee@opgtest.com", ActorID: 47, ActorName: "Anne Admin", Timestamp: now, Direction: history.Joined},
+		{TeamID: 1, UserID: 10, UserName: "Bob", ActorID: 47, ActorName: "Anne Admin", Timestamp: now, Direction: history.Left},
+	}, teamHistory.append.lastEntries)
+}
+
+func TestTeamHistoryRecorderIgnoresEvents(t *testing.T) {
+	team := sirius.Team{ID: 1}
+	changed := sirius.Team{ID: 1, Members: []sirius.TeamMember{{ID: 10}}}
+
+	for name, event := range map[string]logging.AuditEvent{
+		"failed":     {TargetType: "team", Before: team, After: changed, Err: errors.New("oops")},
+		"user":       {TargetType: "user", Before: team, After: changed},
+		"no before":  {TargetType: "team", After: changed},
+		"no members": {TargetType: "team", Before: team, After: team},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			logger := &mockTeamHistoryLogger{}
+			teamHistory := &mockTeamHistory{}
+			recorder := teamHistoryRecorder{logger: logger, history: teamHistory, now: time.Now}
+
+			r, _ := http.NewRequest("POST", "/teams/edit/1", nil)
+			recorder.Audit(r, event)
+
+			assert.Equal(1, logger.mockAuditLogger.count)
+			assert.Equal(0, teamHistory.append.count)
+		})
+	}
+}
+
+func TestTeamHistoryRecorderAppendError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedError := errors.New("oops")
+
+	logger := &mockTeamHistoryLogger{}
+	teamHistory := &mockTeamHistory{}
+	teamHistory.append.err = expectedError
+	recorder := teamHistoryRecorder{logger: logger, history: teamHistory, now: time.Now}
+
+	r, _ := http.NewRequest("POST", "/teams/remove-member/1", nil)
+	recorder.Audit(r, logging.AuditEvent{
+		TargetType: "team",
+		Before:     sirius.Team{ID: 1, Members: []sirius.TeamMember{{ID: 10}}},
+		After:      sirius.Team{ID: 1},
+	})
+
+	assert.Equal(1, logger.mockLogger.count)
+	assert.Equal(r, logger.mockLogger.lastRequest)
+	assert.Equal(expectedError, logger.lastError)
+}
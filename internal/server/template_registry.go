@@ -0,0 +1,57 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"strings"
+)
+
+// templateRegistry records each template that is looked up, along with the
+// vars it will be given, so that they can all be checked before serving.
+type templateRegistry struct {
+	templates map[string]*template.Template
+	names     []string
+	vars      map[string]interface{}
+}
+
+func newTemplateRegistry(templates map[string]*template.Template) *templateRegistry {
+	return &templateRegistry{
+		templates: templates,
+		vars:      map[string]interface{}{},
+	}
+}
+
+func (r *templateRegistry) Get(name string, vars interface{}) Template {
+	if _, ok := r.vars[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.vars[name] = vars
+
+	return jsonTemplate{r.templates[name]}
+}
+
+// Validate executes the "page" block of every template against the zero value
+// of its vars, returning an error describing each template that fails.
+func (r *templateRegistry) Validate() error {
+	var problems []string
+
+	for _, name := range r.names {
+		tmpl := r.templates[name]
+		if tmpl == nil {
+			problems = append(problems, fmt.Sprintf("template %s: not found", name))
+			continue
+		}
+
+		if err := tmpl.ExecuteTemplate(ioutil.Discard, "page", r.vars[name]); err != nil {
+			problems = append(problems, fmt.Sprintf("template %s: %v", name, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
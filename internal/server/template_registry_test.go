@@ -0,0 +1,46 @@
+package server
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateRegistry(t *testing.T) {
+	assert := assert.New(t)
+
+	registry := newTemplateRegistry(map[string]*template.Template{
+		"users.gotmpl": template.Must(template.New("").Parse(`{{ define "page" }}{{ .Search }}{{ end }}`)),
+	})
+
+	tmpl := registry.Get("users.gotmpl", listUsersVars{})
+	assert.Equal(jsonTemplate{registry.templates["users.gotmpl"]}, tmpl)
+
+	assert.Nil(registry.Validate())
+}
+
+func TestTemplateRegistryInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	registry := newTemplateRegistry(map[string]*template.Template{
+		"users.gotmpl": template.Must(template.New("").Parse(`{{ define "page" }}{{ .Missing }}{{ end }}`)),
+		"teams.gotmpl": template.Must(template.New("").Parse(`{{ define "other" }}{{ end }}`)),
+	})
+
+	registry.Get("users.gotmpl", listUsersVars{})
+	registry.Get("teams.gotmpl", listTeamsVars{})
+	registry.Get("team.gotmpl", viewTeamVars{})
+	registry.Get("team.gotmpl", viewTeamVars{})
+
+	err := registry.Validate()
+	assert.NotNil(err)
+
+	problems := strings.Split(err.Error(), "; ")
+	assert.Len(problems, 3)
+	assert.Contains(problems[0], "template users.gotmpl: ")
+	assert.Contains(problems[0], "can't evaluate field Missing in type server.listUsersVars")
+	assert.Contains(problems[1], "template teams.gotmpl: ")
+	assert.Equal("template team.gotmpl: not found", problems[2])
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -21,7 +22,7 @@ type unlockUserVars struct {
 	Errors    sirius.ValidationErrors
 }
 
-func unlockUser(client UnlockUserClient, tmpl Template) Handler {
+func unlockUser(client UnlockUserClient, tmpl Template, audit AuditLogger) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-users", http.MethodPut) {
 			return StatusError(http.StatusForbidden)
@@ -53,6 +54,15 @@ func unlockUser(client UnlockUserClient, tmpl Template) Handler {
 			user.Locked = false
 			err := client.EditUser(ctx, user)
 
+			audit.Audit(r, logging.AuditEvent{
+				Action:     "unlock-user",
+				TargetType: "user",
+				TargetID:   id,
+				Before:     vars.User,
+				After:      user,
+				Err:        err,
+			})
+
 			if _, ok := err.(sirius.ClientError); ok {
 				vars.Errors = sirius.ValidationErrors{
 					"": {
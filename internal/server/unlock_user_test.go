@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 	"github.com/stretchr/testify/assert"
 )
@@ -57,7 +58,7 @@ func TestGetUnlockUser(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/unlock-user/123", nil)
 
-	err := unlockUser(client, template)(client.requiredPermissions(), w, r)
+	err := unlockUser(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.user.count)
@@ -78,7 +79,7 @@ func TestGetUnlockUserNoPermission(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := unlockUser(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := unlockUser(nil, nil, &mockAuditLogger{})(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -94,7 +95,7 @@ func TestGetUnlockUserError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/unlock-user/123", nil)
 
-	err := unlockUser(client, template)(client.requiredPermissions(), w, r)
+	err := unlockUser(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(expectedError, err)
 
 	assert.Equal(1, client.user.count)
@@ -117,7 +118,7 @@ func TestGetUnlockUserBadPath(t *testing.T) {
 			w := httptest.NewRecorder()
 			r, _ := http.NewRequest("GET", path, nil)
 
-			err := unlockUser(client, template)(client.requiredPermissions(), w, r)
+			err := unlockUser(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 			assert.Equal(StatusError(http.StatusNotFound), err)
 
 			assert.Equal(0, client.user.count)
@@ -136,8 +137,9 @@ func TestPostUnlockUser(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/unlock-user/123", nil)
+	audit := &mockAuditLogger{}
 
-	err := unlockUser(client, template)(client.requiredPermissions(), w, r)
+	err := unlockUser(client, template, audit)(client.requiredPermissions(), w, r)
 	assert.Equal(RedirectError("/edit-user/123"), err)
 
 	assert.Equal(1, client.editUser.count)
@@ -150,6 +152,16 @@ func TestPostUnlockUser(t *testing.T) {
 
 	assert.Equal(1, client.user.count)
 	assert.Equal(0, template.count)
+
+	assert.Equal(1, audit.count)
+	assert.Equal(r, audit.lastRequest)
+	assert.Equal(logging.AuditEvent{
+		Action:     "unlock-user",
+		TargetType: "user",
+		TargetID:   123,
+		Before:     client.user.data,
+		After:      client.editUser.lastUser,
+	}, audit.lastEvent())
 }
 
 func TestPostUnlockUserClientError(t *testing.T) {
@@ -162,7 +174,7 @@ func TestPostUnlockUserClientError(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/unlock-user/123", nil)
 
-	err := unlockUser(client, template)(client.requiredPermissions(), w, r)
+	err := unlockUser(client, template, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	assert.Equal(1, client.user.count)
@@ -191,9 +203,11 @@ func TestPostUnlockUserOtherError(t *testing.T) {
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "/unlock-user/123", nil)
+	audit := &mockAuditLogger{}
 
-	err := unlockUser(client, template)(client.requiredPermissions(), w, r)
+	err := unlockUser(client, template, audit)(client.requiredPermissions(), w, r)
 	assert.Equal(expectedErr, err)
+	assert.Equal(expectedErr, audit.lastEvent().Err)
 
 	assert.Equal(1, client.user.count)
 	assert.Equal(1, client.editUser.count)
@@ -207,6 +221,6 @@ func TestPutUnlockUser(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("PUT", "/unlock-user/123", nil)
 
-	err := unlockUser(nil, nil)(client.requiredPermissions(), w, r)
+	err := unlockUser(nil, nil, &mockAuditLogger{})(client.requiredPermissions(), w, r)
 	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
 }
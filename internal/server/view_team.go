@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
 
@@ -16,9 +17,11 @@ type viewTeamVars struct {
 	Path      string
 	XSRFToken string
 	Team      sirius.Team
+	Hash      string
+	History   []history.Entry
 }
 
-func viewTeam(client ViewTeamClient, tmpl Template) Handler {
+func viewTeam(client ViewTeamClient, tmpl Template, teamHistory TeamHistory) Handler {
 	return func(perm sirius.PermissionSet, w http.ResponseWriter, r *http.Request) error {
 		if !perm.HasPermission("v1-teams", http.MethodPut) {
 			return StatusError(http.StatusForbidden)
@@ -40,10 +43,17 @@ func viewTeam(client ViewTeamClient, tmpl Template) Handler {
 			return err
 		}
 
+		entries, err := teamHistory.Team(team.ID)
+		if err != nil {
+			return err
+		}
+
 		vars := viewTeamVars{
 			Path:      r.URL.Path,
 			XSRFToken: ctx.XSRFToken,
 			Team:      team,
+			Hash:      teamHash(team),
+			History:   entries,
 		}
 
 		return tmpl.ExecuteTemplate(w, "page", vars)
@@ -1,10 +1,12 @@
 package server
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 	"github.com/stretchr/testify/assert"
 )
@@ -46,12 +48,16 @@ func TestViewTeam(t *testing.T) {
 	client := &mockViewTeamClient{
 		data: data,
 	}
+	teamHistory := &mockTeamHistory{}
+	teamHistory.team.data = []history.Entry{
+		{TeamID: 16, UserID: 5, UserName: "Stephani Bennard", Direction: history.Joined},
+	}
 	template := &mockTemplate{}
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/16", nil)
 
-	err := viewTeam(client, template)(client.requiredPermissions(), w, r)
+	err := viewTeam(client, template, teamHistory)(client.requiredPermissions(), w, r)
 	assert.Nil(err)
 
 	resp := w.Result()
@@ -60,21 +66,46 @@ func TestViewTeam(t *testing.T) {
 
 	assert.Equal(1, client.count)
 
+	assert.Equal(1, teamHistory.team.count)
+	assert.Equal(16, teamHistory.team.lastID)
+
 	assert.Equal(1, template.count)
 	assert.Equal("page", template.lastName)
 	assert.Equal(viewTeamVars{
-		Path: "/teams/16",
-		Team: data,
+		Path:    "/teams/16",
+		Team:    data,
+		Hash:    teamHash(data),
+		History: teamHistory.team.data,
 	}, template.lastVars)
 }
 
+func TestViewTeamHistoryError(t *testing.T) {
+	assert := assert.New(t)
+
+	expectedError := errors.New("oops")
+
+	client := &mockViewTeamClient{
+		data: sirius.Team{ID: 16},
+	}
+	teamHistory := &mockTeamHistory{}
+	teamHistory.team.err = expectedError
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/teams/16", nil)
+
+	err := viewTeam(client, template, teamHistory)(client.requiredPermissions(), w, r)
+	assert.Equal(expectedError, err)
+	assert.Equal(0, template.count)
+}
+
 func TestViewTeamNoPermission(t *testing.T) {
 	assert := assert.New(t)
 
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/path", nil)
 
-	err := viewTeam(nil, nil)(sirius.PermissionSet{}, w, r)
+	err := viewTeam(nil, nil, nil)(sirius.PermissionSet{}, w, r)
 	assert.Equal(StatusError(http.StatusForbidden), err)
 }
 
@@ -89,7 +120,7 @@ func TestViewTeamNotFound(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/25", nil)
 
-	err := viewTeam(client, template)(client.requiredPermissions(), w, r)
+	err := viewTeam(client, template, &mockTeamHistory{})(client.requiredPermissions(), w, r)
 
 	assert.Equal(StatusError(http.StatusNotFound), err)
 }
@@ -103,7 +134,7 @@ func TestViewTeamBadPath(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("GET", "/teams/jeoi", nil)
 
-	err := viewTeam(client, template)(client.requiredPermissions(), w, r)
+	err := viewTeam(client, template, &mockTeamHistory{})(client.requiredPermissions(), w, r)
 
 	assert.Equal(StatusError(http.StatusNotFound), err)
 }
@@ -115,6 +146,6 @@ func TestPostViewTeam(t *testing.T) {
 	w := httptest.NewRecorder()
 	r, _ := http.NewRequest("POST", "", nil)
 
-	err := viewTeam(nil, nil)(client.requiredPermissions(), w, r)
+	err := viewTeam(nil, nil, nil)(client.requiredPermissions(), w, r)
 	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
 }
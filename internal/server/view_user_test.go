@@ -0,0 +1,165 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockViewUserClient struct {
+	user struct {
+		count   int
+		lastCtx sirius.Context
+		lastID  int
+		data    sirius.AuthUser
+		err     error
+	}
+	teams struct {
+		count   int
+		lastCtx sirius.Context
+		data    []sirius.Team
+		err     error
+	}
+}
+
+func (m *mockViewUserClient) User(ctx sirius.Context, id int) (sirius.AuthUser, error) {
+	m.user.count += 1
+	m.user.lastCtx = ctx
+	m.user.lastID = id
+
+	return m.user.data, m.user.err
+}
+
+func (m *mockViewUserClient) Teams(ctx sirius.Context) ([]sirius.Team, error) {
+	m.teams.count += 1
+	m.teams.lastCtx = ctx
+
+	return m.teams.data, m.teams.err
+}
+
+func (m *mockViewUserClient) requiredPermissions() sirius.PermissionSet {
+	return sirius.PermissionSet{"v1-users": sirius.PermissionGroup{Permissions: []string{"put"}}}
+}
+
+func TestViewUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockViewUserClient{}
+	client.user.data = sirius.AuthUser{
+		ID:           123,
+		Firstname:    "Some",
+		Surname:      "User",
+		Email:        "some.user@opgtest.com",
+		Organisation: "COP User",
+		Roles:        []string{"Manager"},
+		Locked:       true,
+	}
+	client.teams.data = []sirius.Team{
+		{ID: 1, DisplayName: "Allocations", Members: []sirius.TeamMember{{ID: 4}, {ID: 123}}},
+		{ID: 2, DisplayName: "Complaints", Members: []sirius.TeamMember{{ID: 4}}},
+		{ID: 3, DisplayName: "Lay team 1", Members: []sirius.TeamMember{{ID: 123}}},
+	}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/123", nil)
+
+	err := viewUser(client, template)(client.requiredPermissions(), w, r)
+	assert.Nil(err)
+
+	assert.Equal(1, client.user.count)
+	assert.Equal(getContext(r), client.user.lastCtx)
+	assert.Equal(123, client.user.lastID)
+	assert.Equal(1, client.teams.count)
+	assert.Equal(getContext(r), client.teams.lastCtx)
+
+	assert.Equal(1, template.count)
+	assert.Equal("page", template.lastName)
+	assert.Equal(viewUserVars{
+		Path:  "/users/123",
+		User:  client.user.data,
+		Teams: []sirius.Team{client.teams.data[0], client.teams.data[2]},
+	}, template.lastVars)
+}
+
+func TestViewUserPermissions(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockViewUserClient{}
+	template := &mockTemplate{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/123", nil)
+
+	err := viewUser(client, template)(sirius.PermissionSet{
+		"v1-users": sirius.PermissionGroup{Permissions: []string{"put", "delete"}},
+		"v1-teams": sirius.PermissionGroup{Permissions: []string{"put"}},
+	}, w, r)
+	assert.Nil(err)
+
+	vars := template.lastVars.(viewUserVars)
+	assert.True(vars.CanViewTeams)
+	assert.True(vars.CanDeleteUser)
+}
+
+func TestViewUserNoPermission(t *testing.T) {
+	assert := assert.New(t)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/123", nil)
+
+	err := viewUser(nil, nil)(sirius.PermissionSet{}, w, r)
+	assert.Equal(StatusError(http.StatusForbidden), err)
+}
+
+func TestViewUserBadPath(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockViewUserClient{}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/abc", nil)
+
+	err := viewUser(client, nil)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusNotFound), err)
+	assert.Equal(0, client.user.count)
+}
+
+func TestViewUserErrors(t *testing.T) {
+	expectedError := errors.New("oops")
+
+	for name, setup := range map[string]func(*mockViewUserClient){
+		"User":  func(c *mockViewUserClient) { c.user.err = expectedError },
+		"Teams": func(c *mockViewUserClient) { c.teams.err = expectedError },
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			client := &mockViewUserClient{}
+			setup(client)
+			template := &mockTemplate{}
+
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/users/123", nil)
+
+			err := viewUser(client, template)(client.requiredPermissions(), w, r)
+			assert.Equal(expectedError, err)
+			assert.Equal(0, template.count)
+		})
+	}
+}
+
+func TestPostViewUser(t *testing.T) {
+	assert := assert.New(t)
+
+	client := &mockViewUserClient{}
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/users/123", nil)
+
+	err := viewUser(nil, nil)(client.requiredPermissions(), w, r)
+	assert.Equal(StatusError(http.StatusMethodNotAllowed), err)
+}
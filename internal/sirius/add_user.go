@@ -13,13 +13,13 @@ type addUserRequest struct {
 	Roles     []string `json:"roles"`
 }
 
-func (c *Client) AddUser(ctx Context, email, firstName, lastName, organisation string, roles []string) error {
+func (c *Client) AddUser(ctx Context, email, firstName, lastName string, organisation Organisation, roles []string) error {
 	var body bytes.Buffer
 	err := json.NewEncoder(&body).Encode(addUserRequest{
 		Firstname: firstName,
 		Surname:   lastName,
 		Email:     email,
-		Roles:     append([]string{organisation}, roles...),
+		Roles:     append([]string{organisation.String()}, roles...),
 	})
 	if err != nil {
 		return err
@@ -35,7 +35,7 @@ func TestAddUser(t *testing.T) {
 		email         string
 		firstName     string
 		lastName      string
-		organisation  string
+		organisation  Organisation
 		roles         []string
 		expectedError error
 	}{
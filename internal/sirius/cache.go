@@ -0,0 +1,165 @@
+package sirius
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "sirius_cache_requests_total",
+	Help: "Number of reference data lookups, by key and whether they were served fresh, stale or missed the cache.",
+}, []string{"key", "result"})
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+type cacheCall struct {
+	done       chan struct{}
+	generation int
+	value      interface{}
+	err        error
+}
+
+// refreshTimeout limits a refresh in the background, which is no longer tied
+// to the request that started it.
+const refreshTimeout = 30 * time.Second
+
+// cache holds reference data from Sirius for the ttl. An expired entry is
+// still returned while a single call in the background refreshes it, and
+// concurrent lookups of a missing entry share one call to Sirius. Errors are
+// never cached or shared, as they may be caused by the session or request of
+// whoever made the call.
+type cache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	calls      map[string]*cacheCall
+	generation int
+}
+
+func newCache(ttl time.Duration) *cache {
+	return &cache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]cacheEntry{},
+		calls:   map[string]*cacheCall{},
+	}
+}
+
+func (c *cache) get(ctx Context, key string, fetch func(Context) (interface{}, error)) (interface{}, error) {
+	if c.ttl <= 0 {
+		return fetch(ctx)
+	}
+
+	c.mu.Lock()
+
+	if entry, ok := c.entries[key]; ok {
+		if c.now().Before(entry.expires) {
+			c.mu.Unlock()
+			cacheRequests.WithLabelValues(key, "hit").Inc()
+			return entry.value, nil
+		}
+
+		if _, ok := c.calls[key]; !ok {
+			call := c.start(key)
+			go func() {
+				ctx, cancel := detach(ctx)
+				defer cancel()
+
+				c.run(key, call, ctx, fetch)
+			}()
+		}
+
+		c.mu.Unlock()
+		cacheRequests.WithLabelValues(key, "stale").Inc()
+		return entry.value, nil
+	}
+
+	cacheRequests.WithLabelValues(key, "miss").Inc()
+
+	call, ok := c.calls[key]
+	if ok {
+		c.mu.Unlock()
+		<-call.done
+
+		if call.err == nil {
+			return call.value, nil
+		}
+
+		return c.get(ctx, key, fetch)
+	}
+
+	call = c.start(key)
+	c.mu.Unlock()
+
+	c.run(key, call, ctx, fetch)
+	return call.value, call.err
+}
+
+// start records a call for key, so that it is not made again until it
+// finishes. It must be called with the lock held.
+func (c *cache) start(key string) *cacheCall {
+	call := &cacheCall{done: make(chan struct{}), generation: c.generation}
+	c.calls[key] = call
+
+	return call
+}
+
+func (c *cache) run(key string, call *cacheCall, ctx Context, fetch func(Context) (interface{}, error)) {
+	call.value, call.err = fetch(ctx)
+
+	c.mu.Lock()
+	if call.err == nil && call.generation == c.generation {
+		c.entries[key] = cacheEntry{
+			value:   call.value,
+			expires: c.now().Add(c.ttl),
+		}
+	}
+	delete(c.calls, key)
+	c.mu.Unlock()
+
+	close(call.done)
+}
+
+// flush removes every entry. Calls already made to Sirius still return to
+// whoever is waiting on them, but their result is not kept.
+func (c *cache) flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = map[string]cacheEntry{}
+	c.generation++
+}
+
+// detach keeps the session and request ID of ctx for a refresh that may finish
+// after the request that started it, limited to the refreshTimeout.
+func detach(ctx Context) (Context, context.CancelFunc) {
+	detached, cancel := context.WithTimeout(requestid.WithID(context.Background(), requestid.FromContext(ctx.Context)), refreshTimeout)
+
+	return Context{
+		Context:   detached,
+		Cookies:   ctx.Cookies,
+		XSRFToken: ctx.XSRFToken,
+	}, cancel
+}
+
+// SetCacheTTL sets how long reference data, such as roles and team types, is
+// kept before being fetched again. A ttl of zero, the default, disables the
+// cache.
+func (c *Client) SetCacheTTL(ttl time.Duration) {
+	c.cache = newCache(ttl)
+}
+
+// FlushCache removes all cached reference data.
+func (c *Client) FlushCache() {
+	c.cache.flush()
+}
@@ -0,0 +1,269 @@
+package sirius
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
+	"github.com/stretchr/testify/assert"
+)
+
+type cacheFetch struct {
+	mu      sync.Mutex
+	count   int
+	lastCtx Context
+	value   interface{}
+	err     error
+	block   chan struct{}
+}
+
+func (f *cacheFetch) fetch(ctx Context) (interface{}, error) {
+	f.mu.Lock()
+	f.count++
+	f.lastCtx = ctx
+	value, err, block := f.value, f.err, f.block
+	f.mu.Unlock()
+
+	if block != nil {
+		<-block
+	}
+
+	return value, err
+}
+
+func (f *cacheFetch) calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.count
+}
+
+func TestCache(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	c := newCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	f := &cacheFetch{value: "a"}
+
+	for i := 0; i < 3; i++ {
+		value, err := c.get(Context{}, "key", f.fetch)
+		assert.Nil(err)
+		assert.Equal("a", value)
+	}
+	assert.Equal(1, f.calls())
+
+	_, _ = c.get(Context{}, "other", f.fetch)
+	assert.Equal(2, f.calls())
+}
+
+func TestCacheDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(0)
+	f := &cacheFetch{value: "a"}
+
+	for i := 0; i < 3; i++ {
+		_, _ = c.get(Context{}, "key", f.fetch)
+	}
+	assert.Equal(3, f.calls())
+}
+
+func TestCacheErrorNotCached(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(time.Minute)
+	f := &cacheFetch{err: errors.New("err")}
+
+	_, err := c.get(Context{}, "key", f.fetch)
+	assert.Equal(f.err, err)
+
+	f.err = nil
+	f.value = "a"
+
+	value, err := c.get(Context{}, "key", f.fetch)
+	assert.Nil(err)
+	assert.Equal("a", value)
+	assert.Equal(2, f.calls())
+}
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC)
+	c := newCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	f := &cacheFetch{value: "a"}
+	_, _ = c.get(Context{}, "key", f.fetch)
+
+	now = now.Add(2 * time.Minute)
+	f.value = "b"
+	f.block = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for i := 0; i < 3; i++ {
+		value, err := c.get(Context{Context: requestid.WithID(ctx, "abc-123"), XSRFToken: "abcde"}, "key", f.fetch)
+		assert.Nil(err)
+		assert.Equal("a", value)
+	}
+
+	close(f.block)
+	assert.Eventually(func() bool {
+		value, _ := c.get(Context{}, "key", f.fetch)
+		return value == "b"
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(2, f.calls())
+	deadline, ok := f.lastCtx.Context.Deadline()
+	assert.True(ok)
+	assert.WithinDuration(time.Now().Add(refreshTimeout), deadline, time.Second)
+	assert.Equal("abcde", f.lastCtx.XSRFToken)
+	assert.Equal("abc-123", requestid.FromContext(f.lastCtx.Context))
+}
+
+func TestCacheSingleFlight(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(time.Minute)
+	f := &cacheFetch{value: "a", block: make(chan struct{})}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := c.get(Context{}, "key", f.fetch)
+			assert.Nil(err)
+			assert.Equal("a", value)
+		}()
+	}
+
+	assert.Eventually(func() bool { return f.calls() == 1 }, time.Second, time.Millisecond)
+	close(f.block)
+	wg.Wait()
+
+	assert.Equal(1, f.calls())
+}
+
+func TestCacheSingleFlightErrorNotShared(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(time.Minute)
+
+	block := make(chan struct{})
+	var mu sync.Mutex
+	var tokens []string
+
+	fetch := func(ctx Context) (interface{}, error) {
+		mu.Lock()
+		tokens = append(tokens, ctx.XSRFToken)
+		mu.Unlock()
+
+		if ctx.XSRFToken == "expired" {
+			<-block
+			return nil, ErrUnauthorized
+		}
+
+		return "a", nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_, err := c.get(Context{XSRFToken: "expired"}, "key", fetch)
+		assert.Equal(ErrUnauthorized, err)
+		close(done)
+	}()
+
+	assert.Eventually(func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(tokens) == 1
+	}, time.Second, time.Millisecond)
+
+	waited := make(chan struct{})
+	go func() {
+		value, err := c.get(Context{XSRFToken: "valid"}, "key", fetch)
+		assert.Nil(err)
+		assert.Equal("a", value)
+		close(waited)
+	}()
+
+	close(block)
+	<-done
+	<-waited
+
+	assert.Equal([]string{"expired", "valid"}, tokens)
+}
+
+func TestCacheFlush(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(time.Minute)
+	f := &cacheFetch{value: "a"}
+
+	_, _ = c.get(Context{}, "key", f.fetch)
+	c.flush()
+	_, _ = c.get(Context{}, "key", f.fetch)
+
+	assert.Equal(2, f.calls())
+}
+
+func TestCacheFlushDuringCall(t *testing.T) {
+	assert := assert.New(t)
+
+	c := newCache(time.Minute)
+	f := &cacheFetch{value: "a", block: make(chan struct{})}
+
+	done := make(chan struct{})
+	go func() {
+		value, _ := c.get(Context{}, "key", f.fetch)
+		assert.Equal("a", value)
+		close(done)
+	}()
+
+	assert.Eventually(func() bool { return f.calls() == 1 }, time.Second, time.Millisecond)
+	c.flush()
+	close(f.block)
+	<-done
+
+	f.block = nil
+	f.value = "b"
+
+	value, _ := c.get(Context{}, "key", f.fetch)
+	assert.Equal("b", value)
+}
+
+func TestClientCachesReferenceData(t *testing.T) {
+	assert := assert.New(t)
+
+	count := 0
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		_, _ = w.Write([]byte(`["System Admin", "OPG User"]`))
+	}))
+	defer s.Close()
+
+	client, _ := NewClient(http.DefaultClient, s.URL)
+	client.SetCacheTTL(time.Minute)
+
+	for i := 0; i < 2; i++ {
+		roles, err := client.Roles(Context{Context: context.Background()})
+		assert.Nil(err)
+		assert.Equal([]string{"System Admin"}, roles)
+	}
+	assert.Equal(1, count)
+
+	client.FlushCache()
+
+	_, _ = client.Roles(Context{Context: context.Background()})
+	assert.Equal(2, count)
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
 )
 
 const ErrUnauthorized ClientError = "unauthorized"
@@ -59,15 +61,36 @@ type Context struct {
 }
 
 func NewClient(httpClient *http.Client, baseURL string) (*Client, error) {
+	next := httpClient.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	instrumented := *httpClient
+	instrumented.Transport = metricsTransport{next: next}
+
+	// Ping goes around any retries and breaker, so that a health check neither
+	// waits on them nor closes the breaker with its unauthorized response
+	ping := instrumented
+	if t, ok := next.(*Transport); ok {
+		ping.Transport = metricsTransport{next: t.next}
+	}
+
 	return &Client{
-		http:    httpClient,
-		baseURL: baseURL,
+		http:          &instrumented,
+		ping:          &ping,
+		baseURL:       baseURL,
+		organisations: DefaultOrganisations,
+		cache:         newCache(0),
 	}, nil
 }
 
 type Client struct {
-	http    *http.Client
-	baseURL string
+	http          *http.Client
+	ping          *http.Client
+	baseURL       string
+	organisations []Organisation
+	cache         *cache
 }
 
 func (c *Client) newRequest(ctx Context, method, path string, body io.Reader) (*http.Request, error) {
@@ -83,5 +106,9 @@ func (c *Client) newRequest(ctx Context, method, path string, body io.Reader) (*
 	req.Header.Add("OPG-Bypass-Membrane", "1")
 	req.Header.Add("X-XSRF-TOKEN", ctx.XSRFToken)
 
+	if id := requestid.FromContext(ctx.Context); id != "" {
+		req.Header.Add(requestid.Header, id)
+	}
+
 	return req, err
 }
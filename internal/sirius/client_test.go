@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/requestid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -55,3 +56,17 @@ func TestStatusError(t *testing.T) {
 	assert.Equal(t, "unexpected response from Sirius", err.Title())
 	assert.Equal(t, err, err.Data())
 }
+
+func TestNewRequestRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	client, _ := NewClient(http.DefaultClient, "http://sirius")
+
+	ctx := getContext(nil)
+	req, _ := client.newRequest(ctx, http.MethodGet, "/path", nil)
+	assert.Empty(req.Header.Values("X-Request-ID"))
+
+	ctx.Context = requestid.WithID(ctx.Context, "abc-123")
+	req, _ = client.newRequest(ctx, http.MethodGet, "/path", nil)
+	assert.Equal("abc-123", req.Header.Get("X-Request-ID"))
+}
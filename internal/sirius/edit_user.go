@@ -22,7 +22,7 @@ func (c *Client) EditUser(ctx Context, user AuthUser) error {
 		ID:        user.ID,
 		Firstname: user.Firstname,
 		Surname:   user.Surname,
-		Roles:     append(user.Roles, user.Organisation),
+		Roles:     append(user.Roles, user.Organisation.String()),
 		Locked:    user.Locked,
 		Suspended: user.Suspended,
 	})
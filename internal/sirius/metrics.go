@@ -0,0 +1,65 @@
+package sirius
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sirius_requests_total",
+		Help: "Number of requests made to Sirius, by method, path template and status code.",
+	}, []string{"method", "path", "code"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sirius_request_duration_seconds",
+		Help:    "Time taken for Sirius to respond, by method, path template and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path", "code"})
+)
+
+// metricsTransport records each call made by the Client. Requests that fail
+// without a response, including when the breaker is open, have the code
+// "error".
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+func (t metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+
+	labels := prometheus.Labels{
+		"method": req.Method,
+		"path":   pathTemplate(req.URL.Path),
+		"code":   code,
+	}
+
+	requestsTotal.With(labels).Inc()
+	requestDuration.With(labels).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
+
+// pathTemplate replaces IDs in a path so that requests for different
+// resources are counted against the same endpoint.
+func pathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
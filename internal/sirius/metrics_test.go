@@ -0,0 +1,49 @@
+package sirius
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientMetrics(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer s.Close()
+
+	client, _ := NewClient(http.DefaultClient, s.URL)
+
+	counter := requestsTotal.WithLabelValues(http.MethodGet, "/auth/user/{id}", "418")
+	before := testutil.ToFloat64(counter)
+
+	_, _ = client.User(getContext(nil), 123)
+	_, _ = client.User(getContext(nil), 456)
+
+	assert.Equal(t, before+2, testutil.ToFloat64(counter))
+	assert.Nil(t, http.DefaultClient.Transport)
+}
+
+func TestClientMetricsError(t *testing.T) {
+	client, _ := NewClient(http.DefaultClient, "http://localhost:0")
+
+	counter := requestsTotal.WithLabelValues(http.MethodGet, "/api/v1/teams/{id}", "error")
+	before := testutil.ToFloat64(counter)
+
+	_, _ = client.Team(getContext(nil), 5)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestPathTemplate(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/api/v1/teams":                         "/api/v1/teams",
+		"/api/v1/teams/12":                      "/api/v1/teams/{id}",
+		"/api/v1/users/5/updateTelephoneNumber": "/api/v1/users/{id}/updateTelephoneNumber",
+	} {
+		assert.Equal(t, expected, pathTemplate(path))
+	}
+}
@@ -6,18 +6,19 @@ import (
 )
 
 type MyDetails struct {
-	ID          int             `json:"id"`
-	Name        string          `json:"name"`
-	PhoneNumber string          `json:"phoneNumber"`
-	Teams       []MyDetailsTeam `json:"teams"`
-	DisplayName string          `json:"displayName"`
-	Deleted     bool            `json:"deleted"`
-	Email       string          `json:"email"`
-	Firstname   string          `json:"firstname"`
-	Surname     string          `json:"surname"`
-	Roles       []string        `json:"roles"`
-	Locked      bool            `json:"locked"`
-	Suspended   bool            `json:"suspended"`
+	ID           int             `json:"id"`
+	Name         string          `json:"name"`
+	PhoneNumber  string          `json:"phoneNumber"`
+	Teams        []MyDetailsTeam `json:"teams"`
+	DisplayName  string          `json:"displayName"`
+	Deleted      bool            `json:"deleted"`
+	Email        string          `json:"email"`
+	Firstname    string          `json:"firstname"`
+	Surname      string          `json:"surname"`
+	Roles        []string        `json:"roles"`
+	Organisation Organisation    `json:"-"`
+	Locked       bool            `json:"locked"`
+	Suspended    bool            `json:"suspended"`
 }
 
 type MyDetailsTeam struct {
@@ -47,5 +48,7 @@ func (c *Client) MyDetails(ctx Context) (MyDetails, error) {
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(&v)
+	v.Organisation, v.Roles = c.splitRoles(v.Roles)
+
 	return v, err
 }
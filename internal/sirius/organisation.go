@@ -0,0 +1,68 @@
+package sirius
+
+import "strings"
+
+// Organisation is the part of the business a user works in. Sirius holds it
+// as one of the user's roles, but a user has exactly one so it is shown and
+// chosen separately.
+type Organisation string
+
+func (o Organisation) String() string {
+	return string(o)
+}
+
+// DefaultOrganisations are the organisations known to a new Client.
+var DefaultOrganisations = []Organisation{"OPG User", "COP User"}
+
+// ParseOrganisations reads a comma separated list of organisations.
+func ParseOrganisations(s string) []Organisation {
+	var organisations []Organisation
+
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			organisations = append(organisations, Organisation(name))
+		}
+	}
+
+	return organisations
+}
+
+// Organisations returns the known organisations, in the order they should be
+// offered.
+func (c *Client) Organisations() []Organisation {
+	return c.organisations
+}
+
+// SetOrganisations replaces the known organisations.
+func (c *Client) SetOrganisations(organisations []Organisation) {
+	c.organisations = organisations
+}
+
+func (c *Client) isOrganisation(role string) bool {
+	for _, organisation := range c.organisations {
+		if organisation.String() == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitRoles separates the organisation from the rest of the roles Sirius
+// gives for a user.
+func (c *Client) splitRoles(all []string) (Organisation, []string) {
+	var (
+		organisation Organisation
+		roles        []string
+	)
+
+	for _, role := range all {
+		if c.isOrganisation(role) {
+			organisation = Organisation(role)
+		} else {
+			roles = append(roles, role)
+		}
+	}
+
+	return organisation, roles
+}
@@ -0,0 +1,30 @@
+package sirius
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOrganisations(t *testing.T) {
+	assert.Equal(t, []Organisation{"OPG User", "COP User", "Other User"}, ParseOrganisations("OPG User, COP User,,Other User "))
+	assert.Nil(t, ParseOrganisations(""))
+}
+
+func TestSplitRoles(t *testing.T) {
+	assert := assert.New(t)
+
+	client, _ := NewClient(http.DefaultClient, "")
+	assert.Equal(DefaultOrganisations, client.Organisations())
+
+	organisation, roles := client.splitRoles([]string{"System Admin", "COP User", "Manager"})
+	assert.Equal(Organisation("COP User"), organisation)
+	assert.Equal([]string{"System Admin", "Manager"}, roles)
+
+	client.SetOrganisations([]Organisation{"Other User"})
+
+	organisation, roles = client.splitRoles([]string{"COP User", "Other User"})
+	assert.Equal(Organisation("Other User"), organisation)
+	assert.Equal([]string{"COP User"}, roles)
+}
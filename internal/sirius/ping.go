@@ -0,0 +1,27 @@
+package sirius
+
+import (
+	"net/http"
+)
+
+// Ping checks that Sirius is answering requests. It is made without a
+// session, so an unauthorized response is expected and only a server error
+// counts as a failure.
+func (c *Client) Ping(ctx Context) error {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/v1/users/current", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.ping.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return newStatusError(resp)
+	}
+
+	return nil
+}
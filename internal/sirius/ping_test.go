@@ -0,0 +1,71 @@
+package sirius
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPing(t *testing.T) {
+	for name, code := range map[string]int{
+		"OK":           http.StatusOK,
+		"Unauthorized": http.StatusUnauthorized,
+	} {
+		t.Run(name, func(t *testing.T) {
+			s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(code)
+			}))
+			defer s.Close()
+
+			client, _ := NewClient(http.DefaultClient, s.URL)
+
+			assert.Nil(t, client.Ping(getContext(nil)))
+		})
+	}
+}
+
+func TestPingServerError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer s.Close()
+
+	client, _ := NewClient(http.DefaultClient, s.URL)
+
+	assert.Equal(t, StatusError{
+		Code:   http.StatusBadGateway,
+		URL:    s.URL + "/api/v1/users/current",
+		Method: http.MethodGet,
+	}, client.Ping(getContext(nil)))
+}
+
+func TestPingBypassesTransport(t *testing.T) {
+	assert := assert.New(t)
+
+	var count int32
+	s := statusSequenceServer(&count, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusUnauthorized)
+	defer s.Close()
+
+	client, _ := NewClient(transportClient(TransportOptions{RetryAttempts: 2, BreakerThreshold: 1, BreakerCooldown: time.Hour}), s.URL)
+
+	_, err := client.MyPermissions(getContext(nil))
+	assert.IsType(StatusError{}, err)
+	assert.Equal(int32(3), count)
+
+	assert.Nil(client.Ping(getContext(nil)))
+	assert.Equal(int32(4), count)
+
+	_, err = client.MyPermissions(getContext(nil))
+	assert.True(errors.Is(err, ErrUnavailable))
+	assert.Equal(int32(4), count)
+}
+
+func TestPingUnreachable(t *testing.T) {
+	client, _ := NewClient(http.DefaultClient, "http://localhost:0")
+
+	assert.NotNil(t, client.Ping(getContext(nil)))
+}
@@ -6,7 +6,19 @@ import (
 	"sort"
 )
 
+// Roles returns the roles that can be given to a user, other than their
+// organisation. The result may be shared with other callers so must not be
+// changed.
 func (c *Client) Roles(ctx Context) ([]string, error) {
+	v, err := c.cache.get(ctx, "roles", func(ctx Context) (interface{}, error) {
+		return c.roles(ctx)
+	})
+
+	roles, _ := v.([]string)
+	return roles, err
+}
+
+func (c *Client) roles(ctx Context) ([]string, error) {
 	var v []string
 
 	req, err := c.newRequest(ctx, http.MethodGet, "/api/v1/roles", nil)
@@ -32,7 +44,7 @@ func (c *Client) Roles(ctx Context) ([]string, error) {
 
 	var roles []string
 	for _, role := range v {
-		if role != "COP User" && role != "OPG User" {
+		if !c.isOrganisation(role) {
 			roles = append(roles, role)
 		}
 	}
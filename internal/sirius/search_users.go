@@ -33,11 +33,15 @@ type apiUser struct {
 	Suspended   bool   `json:"suspended"`
 }
 
+// User is a result of SearchUsers. Organisation and Roles are not part of the
+// results, so are only set once the user's details have been fetched.
 type User struct {
-	ID          int    `json:"id"`
-	DisplayName string `json:"displayName"`
-	Email       string `json:"email"`
-	Status      UserStatus
+	ID           int    `json:"id"`
+	DisplayName  string `json:"displayName"`
+	Email        string `json:"email"`
+	Status       UserStatus
+	Organisation Organisation
+	Roles        []string
 }
 
 func (c *Client) SearchUsers(ctx Context, search string) ([]User, error) {
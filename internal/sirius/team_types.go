@@ -10,7 +10,18 @@ type RefDataTeamType struct {
 	Label  string `json:"label"`
 }
 
+// TeamTypes returns the types a supervision team can have. The result may be
+// shared with other callers so must not be changed.
 func (c *Client) TeamTypes(ctx Context) ([]RefDataTeamType, error) {
+	v, err := c.cache.get(ctx, "team-types", func(ctx Context) (interface{}, error) {
+		return c.teamTypes(ctx)
+	})
+
+	teamTypes, _ := v.([]RefDataTeamType)
+	return teamTypes, err
+}
+
+func (c *Client) teamTypes(ctx Context) ([]RefDataTeamType, error) {
 	var v struct {
 		Data []RefDataTeamType `json:"teamType"`
 	}
@@ -74,6 +74,7 @@ func (c *Client) Teams(ctx Context) ([]Team, error) {
 
 		for _, m := range t.Members {
 			teams[i].Members = append(teams[i].Members, TeamMember{
+				ID:          m.ID,
 				DisplayName: m.DisplayName,
 				Email:       m.Email,
 			})
@@ -3,6 +3,7 @@ package sirius
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/pact-foundation/pact-go/dsl"
@@ -110,6 +111,7 @@ func TestTeamsIgnoredPact(t *testing.T) {
 							"id":          dsl.Like(123),
 							"displayName": dsl.Like("Cool Team"),
 							"members": dsl.EachLike(map[string]interface{}{
+								"id":          dsl.Like(123),
 								"displayName": dsl.Like("John"),
 								"email":       dsl.Like("john@opgtest.com"),
 							}, 1),
@@ -130,6 +132,7 @@ func TestTeamsIgnoredPact(t *testing.T) {
 					DisplayName: "Cool Team",
 					Members: []TeamMember{
 						{
+							ID:          123,
 							DisplayName: "John",
 							Email:       "john@opgtest.com",
 						},
@@ -163,6 +166,7 @@ func TestTeamsIgnoredPact(t *testing.T) {
 							"id":          dsl.Like(123),
 							"displayName": dsl.Like("Cool Team"),
 							"members": dsl.EachLike(map[string]interface{}{
+								"id":          dsl.Like(123),
 								"displayName": dsl.Like("John"),
 								"email":       dsl.Like("john@opgtest.com"),
 							}, 1),
@@ -179,6 +183,7 @@ func TestTeamsIgnoredPact(t *testing.T) {
 					DisplayName: "Cool Team",
 					Members: []TeamMember{
 						{
+							ID:          123,
 							DisplayName: "John",
 							Email:       "john@opgtest.com",
 						},
@@ -219,3 +224,16 @@ func TestTeamsStatusError(t *testing.T) {
 		Method: http.MethodGet,
 	}, err)
 }
+
+func TestTeamsMemberIDs(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"id":123,"displayName":"Cool Team","members":[{"id":47,"displayName":"John","email":"john@opgtest.com"}]}]`))
+	}))
+	defer s.Close()
+
+	client, _ := NewClient(http.DefaultClient, s.URL)
+
+	teams, err := client.Teams(getContext(nil))
+	assert.Nil(t, err)
+	assert.Equal(t, []TeamMember{{ID: 47, DisplayName: "John", Email: "john@opgtest.com"}}, teams[0].Members)
+}
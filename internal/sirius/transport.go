@@ -0,0 +1,146 @@
+package sirius
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ErrUnavailable = errors.New("Sirius is unavailable")
+
+type TransportOptions struct {
+	// RetryAttempts is the number of times an idempotent request will be
+	// retried after a transient failure.
+	RetryAttempts  int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+
+	// BreakerThreshold is the number of consecutive failures after which
+	// requests fail fast with ErrUnavailable, a value of 0 disables the
+	// breaker. It stays open for BreakerCooldown before letting a single
+	// request through to test whether Sirius has recovered.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type Transport struct {
+	next http.RoundTripper
+	opts TransportOptions
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func NewTransport(next http.RoundTripper, opts TransportOptions) *Transport {
+	return &Transport{next: next, opts: opts}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.allow() {
+		return nil, ErrUnavailable
+	}
+
+	attempts := 1
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		attempts += t.opts.RetryAttempts
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+
+		if req.Context().Err() != nil {
+			t.release()
+			return resp, err
+		}
+
+		failed := isTransientFailure(resp, err)
+		if !failed || attempt+1 >= attempts {
+			t.record(failed)
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(t.backoff(attempt)):
+		case <-req.Context().Done():
+			t.release()
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+func (t *Transport) allow() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.opts.BreakerThreshold <= 0 || t.failures < t.opts.BreakerThreshold {
+		return true
+	}
+
+	if t.probing || time.Now().Before(t.openUntil) {
+		return false
+	}
+
+	t.probing = true
+	return true
+}
+
+func (t *Transport) record(failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.probing = false
+
+	if !failed {
+		t.failures = 0
+		return
+	}
+
+	t.failures++
+	if t.opts.BreakerThreshold > 0 && t.failures >= t.opts.BreakerThreshold {
+		t.openUntil = time.Now().Add(t.opts.BreakerCooldown)
+	}
+}
+
+func (t *Transport) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.probing = false
+}
+
+func (t *Transport) backoff(attempt int) time.Duration {
+	delay := t.opts.RetryBaseDelay << uint(attempt)
+	if t.opts.RetryMaxDelay > 0 && (delay > t.opts.RetryMaxDelay || delay <= 0) {
+		delay = t.opts.RetryMaxDelay
+	}
+
+	if delay <= 1 {
+		return delay
+	}
+
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
+
+func isTransientFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
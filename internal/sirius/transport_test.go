@@ -0,0 +1,173 @@
+package sirius
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func statusSequenceServer(count *int32, codes ...int) *httptest.Server {
+	return httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			i := int(atomic.AddInt32(count, 1)) - 1
+			if i >= len(codes) {
+				i = len(codes) - 1
+			}
+
+			w.WriteHeader(codes[i])
+		}),
+	)
+}
+
+func transportClient(opts TransportOptions) *http.Client {
+	return &http.Client{Transport: NewTransport(http.DefaultTransport, opts)}
+}
+
+func TestTransportRetriesGet(t *testing.T) {
+	assert := assert.New(t)
+
+	var count int32
+	s := statusSequenceServer(&count, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK)
+	defer s.Close()
+
+	client := transportClient(TransportOptions{RetryAttempts: 2, RetryBaseDelay: time.Millisecond})
+
+	resp, err := client.Get(s.URL)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(int32(3), count)
+}
+
+func TestTransportGivesUpAfterRetries(t *testing.T) {
+	assert := assert.New(t)
+
+	var count int32
+	s := statusSequenceServer(&count, http.StatusGatewayTimeout)
+	defer s.Close()
+
+	client := transportClient(TransportOptions{RetryAttempts: 2, RetryBaseDelay: time.Millisecond})
+
+	resp, err := client.Get(s.URL)
+	assert.Nil(err)
+	assert.Equal(http.StatusGatewayTimeout, resp.StatusCode)
+	assert.Equal(int32(3), count)
+}
+
+func TestTransportDoesNotRetryOtherStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	var count int32
+	s := statusSequenceServer(&count, http.StatusInternalServerError, http.StatusOK)
+	defer s.Close()
+
+	client := transportClient(TransportOptions{RetryAttempts: 2, RetryBaseDelay: time.Millisecond})
+
+	resp, err := client.Get(s.URL)
+	assert.Nil(err)
+	assert.Equal(http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(int32(1), count)
+}
+
+func TestTransportDoesNotRetryPost(t *testing.T) {
+	assert := assert.New(t)
+
+	var count int32
+	s := statusSequenceServer(&count, http.StatusBadGateway, http.StatusOK)
+	defer s.Close()
+
+	client := transportClient(TransportOptions{RetryAttempts: 2, RetryBaseDelay: time.Millisecond})
+
+	resp, err := client.Post(s.URL, "text/plain", strings.NewReader("hey"))
+	assert.Nil(err)
+	assert.Equal(http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(int32(1), count)
+}
+
+func TestTransportStopsRetryingWhenCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	var count int32
+	s := statusSequenceServer(&count, http.StatusBadGateway)
+	defer s.Close()
+
+	client := transportClient(TransportOptions{RetryAttempts: 5, RetryBaseDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	_, err := client.Do(req)
+	assert.True(errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(int32(1), count)
+}
+
+func TestTransportBreakerOpens(t *testing.T) {
+	assert := assert.New(t)
+
+	var count int32
+	s := statusSequenceServer(&count, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK)
+	defer s.Close()
+
+	client := transportClient(TransportOptions{BreakerThreshold: 2, BreakerCooldown: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(s.URL)
+		assert.Nil(err)
+		assert.Equal(http.StatusBadGateway, resp.StatusCode)
+	}
+
+	_, err := client.Get(s.URL)
+	assert.True(errors.Is(err, ErrUnavailable))
+	assert.Equal(int32(2), count)
+}
+
+func TestTransportBreakerRecovers(t *testing.T) {
+	assert := assert.New(t)
+
+	var count int32
+	s := statusSequenceServer(&count, http.StatusBadGateway, http.StatusOK)
+	defer s.Close()
+
+	client := transportClient(TransportOptions{BreakerThreshold: 1, BreakerCooldown: 10 * time.Millisecond})
+
+	resp, err := client.Get(s.URL)
+	assert.Nil(err)
+	assert.Equal(http.StatusBadGateway, resp.StatusCode)
+
+	_, err = client.Get(s.URL)
+	assert.True(errors.Is(err, ErrUnavailable))
+
+	time.Sleep(20 * time.Millisecond)
+
+	resp, err = client.Get(s.URL)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+
+	resp, err = client.Get(s.URL)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, resp.StatusCode)
+	assert.Equal(int32(3), count)
+}
+
+func TestTransportBreakerThroughClient(t *testing.T) {
+	assert := assert.New(t)
+
+	var count int32
+	s := statusSequenceServer(&count, http.StatusBadGateway)
+	defer s.Close()
+
+	client, _ := NewClient(transportClient(TransportOptions{BreakerThreshold: 1, BreakerCooldown: time.Hour}), s.URL)
+
+	_, err := client.MyPermissions(getContext(nil))
+	assert.IsType(StatusError{}, err)
+
+	_, err = client.MyPermissions(getContext(nil))
+	assert.True(errors.Is(err, ErrUnavailable))
+}
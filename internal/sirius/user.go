@@ -11,7 +11,7 @@ type AuthUser struct {
 	Firstname    string
 	Surname      string
 	Email        string
-	Organisation string
+	Organisation Organisation
 	Roles        []string
 	Locked       bool
 	Suspended    bool
@@ -64,13 +64,7 @@ func (c *Client) User(ctx Context, id int) (AuthUser, error) {
 		Inactive:  v.Inactive,
 	}
 
-	for _, role := range v.Roles {
-		if role == "OPG User" || role == "COP User" {
-			user.Organisation = role
-		} else {
-			user.Roles = append(user.Roles, role)
-		}
-	}
+	user.Organisation, user.Roles = c.splitRoles(v.Roles)
 
 	return user, err
 }
@@ -2,16 +2,20 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"html/template"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/history"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/logging"
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/policy"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/server"
 	"github.com/ministryofjustice/opg-sirius-user-management/internal/sirius"
 )
@@ -19,51 +23,85 @@ import (
 func main() {
 	logger := logging.New(os.Stdout, "opg-sirius-user-management")
 
+	logLevel, err := logging.ParseLevel(getEnv("LOG_LEVEL", "info"))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	logger.SetLevel(logLevel)
+
+	redactParams := getEnv("LOG_REDACT_PARAMS", strings.Join(logging.DefaultRedactParams, ","))
+	logger.SetRedactor(logging.NewRedactor(strings.Split(redactParams, ","), logging.EmailPattern, logging.PhonePattern))
+
 	port := getEnv("PORT", "8080")
 	webDir := getEnv("WEB_DIR", "web")
 	siriusURL := getEnv("SIRIUS_URL", "http://localhost:9001")
 	siriusPublicURL := getEnv("SIRIUS_PUBLIC_URL", "")
 	prefix := getEnv("PREFIX", "")
 
-	layouts, _ := template.
-		New("").
-		Funcs(map[string]interface{}{
-			"join": func(sep string, items []string) string {
-				return strings.Join(items, sep)
-			},
-			"contains": func(xs []string, needle string) bool {
-				for _, x := range xs {
-					if x == needle {
-						return true
-					}
-				}
+	tmpls, err := loadTemplates(webDir, prefix, siriusPublicURL)
+	if err != nil {
+		logger.Fatal(err)
+	}
 
-				return false
-			},
-			"prefix": func(s string) string {
-				return prefix + s
-			},
-			"sirius": func(s string) string {
-				return siriusPublicURL + s
-			},
-		}).
-		ParseGlob(webDir + "/template/layout/*.gotmpl")
+	timeout, err := getEnvDuration("SIRIUS_TIMEOUT", "10s")
+	if err != nil {
+		logger.Fatal(err)
+	}
 
-	files, _ := filepath.Glob(webDir + "/template/*.gotmpl")
-	tmpls := map[string]*template.Template{}
+	transportOptions, err := getTransportOptions()
+	if err != nil {
+		logger.Fatal(err)
+	}
 
-	for _, file := range files {
-		tmpls[filepath.Base(file)] = template.Must(template.Must(layouts.Clone()).ParseFiles(file))
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	httpTransport.ResponseHeaderTimeout = timeout
+
+	httpClient := &http.Client{
+		Transport: sirius.NewTransport(httpTransport, transportOptions),
+	}
+
+	client, err := sirius.NewClient(httpClient, siriusURL)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	client.SetOrganisations(sirius.ParseOrganisations(getEnv("ORGANISATIONS", "OPG User,COP User")))
+
+	referenceDataTTL, err := getEnvDuration("REFERENCE_DATA_CACHE_TTL", "5m")
+	if err != nil {
+		logger.Fatal(err)
 	}
+	client.SetCacheTTL(referenceDataTTL)
 
-	client, err := sirius.NewClient(http.DefaultClient, siriusURL)
+	permissionsTTL, err := getEnvDuration("PERMISSIONS_CACHE_TTL", "10s")
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	teamHistory, err := history.Open(getEnv("HISTORY_FILE", "history.jsonl"))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer teamHistory.Close()
+
+	suspensions, err := history.OpenSuspensions(getEnv("SUSPENSIONS_FILE", "suspensions.jsonl"))
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer suspensions.Close()
+
+	rolePolicy, err := policy.Load(getEnv("ROLE_POLICY_FILE", ""))
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	handler, err := server.New(logger, client, teamHistory, suspensions, rolePolicy, tmpls, prefix, siriusURL, siriusPublicURL, webDir, permissionsTTL)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
 	server := &http.Server{
 		Addr:    ":" + port,
-		Handler: server.New(logger, client, tmpls, prefix, siriusURL, siriusPublicURL, webDir),
+		Handler: handler,
 	}
 
 	go func() {
@@ -88,6 +126,63 @@ func main() {
 	}
 }
 
+func loadTemplates(webDir, prefix, siriusPublicURL string) (map[string]*template.Template, error) {
+	london, err := time.LoadLocation("Europe/London")
+	if err != nil {
+		return nil, err
+	}
+
+	layouts, err := template.
+		New("").
+		Funcs(map[string]interface{}{
+			"join": func(sep string, items []string) string {
+				return strings.Join(items, sep)
+			},
+			"contains": func(xs []string, needle string) bool {
+				for _, x := range xs {
+					if x == needle {
+						return true
+					}
+				}
+
+				return false
+			},
+			"prefix": func(s string) string {
+				return prefix + s
+			},
+			"sirius": func(s string) string {
+				return siriusPublicURL + s
+			},
+			"london": func(t time.Time) time.Time {
+				return t.In(london)
+			},
+		}).
+		ParseGlob(webDir + "/template/layout/*.gotmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(webDir + "/template/*.gotmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	tmpls := map[string]*template.Template{}
+
+	for _, file := range files {
+		clone, err := layouts.Clone()
+		if err != nil {
+			return nil, err
+		}
+
+		if tmpls[filepath.Base(file)], err = clone.ParseFiles(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return tmpls, nil
+}
+
 func getEnv(key, def string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -95,3 +190,45 @@ func getEnv(key, def string) string {
 
 	return def
 }
+
+func getEnvInt(key, def string) (int, error) {
+	v, err := strconv.Atoi(getEnv(key, def))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return v, nil
+}
+
+func getEnvDuration(key, def string) (time.Duration, error) {
+	v, err := time.ParseDuration(getEnv(key, def))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+
+	return v, nil
+}
+
+func getTransportOptions() (opts sirius.TransportOptions, err error) {
+	if opts.RetryAttempts, err = getEnvInt("SIRIUS_RETRY_ATTEMPTS", "2"); err != nil {
+		return opts, err
+	}
+
+	if opts.RetryBaseDelay, err = getEnvDuration("SIRIUS_RETRY_BASE_DELAY", "100ms"); err != nil {
+		return opts, err
+	}
+
+	if opts.RetryMaxDelay, err = getEnvDuration("SIRIUS_RETRY_MAX_DELAY", "1s"); err != nil {
+		return opts, err
+	}
+
+	if opts.BreakerThreshold, err = getEnvInt("SIRIUS_BREAKER_THRESHOLD", "5"); err != nil {
+		return opts, err
+	}
+
+	if opts.BreakerCooldown, err = getEnvDuration("SIRIUS_BREAKER_COOLDOWN", "30s"); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
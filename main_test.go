@@ -1 +1,46 @@
 package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ministryofjustice/opg-sirius-user-management/internal/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplates(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpls, err := loadTemplates("web", "/prefix", "http://sirius")
+	assert.Nil(err)
+
+	_, err = server.New(nil, nil, nil, nil, nil, tmpls, "/prefix", "http://sirius", "http://sirius", "web", 0)
+	assert.Nil(err)
+}
+
+func TestTemplatesLondon(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpls, err := loadTemplates("web", "/prefix", "http://sirius")
+	assert.Nil(err)
+
+	tmpl, err := tmpls["team.gotmpl"].Clone()
+	assert.Nil(err)
+	_, err = tmpl.Parse(`{{ define "test" }}{{ (london .).Format "2 January 2006 15:04" }}{{ end }}`)
+	assert.Nil(err)
+
+	for utc, expected := range map[time.Time]string{
+		time.Date(2021, time.January, 2, 13, 4, 0, 0, time.UTC): "2 January 2021 13:04",
+		time.Date(2021, time.July, 2, 23, 4, 0, 0, time.UTC):    "3 July 2021 00:04",
+	} {
+		var buf strings.Builder
+		assert.Nil(tmpl.ExecuteTemplate(&buf, "test", utc))
+		assert.Equal(expected, buf.String())
+	}
+}
+
+func TestLoadTemplatesMissingDir(t *testing.T) {
+	_, err := loadTemplates("does-not-exist", "", "")
+	assert.NotNil(t, err)
+}